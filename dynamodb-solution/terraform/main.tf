@@ -19,13 +19,11 @@ module "logging" {
   retention_in_days = var.log_retention_days
 }
 
-/*
-# --- HW7: Messaging Module (SNS & SQS) ---
-# We don't need this for the shopping cart API
+# --- Messaging Module (SNS & SQS) ---
+# Checkout publishes OrderPlaced events to the order-processing-events topic
 module "messaging" {
   source = "./modules/messaging"
 }
-*/
 
 # This data source is used by your ECS module, so we keep it.
 # This tells us your task role is named "LabRole".
@@ -97,56 +95,52 @@ module "ecs" {
     "DATABASE_TYPE"       = "dynamodb" # For the "switcher" logic
     "DYNAMODB_TABLE_NAME" = module.dynamodb.table_name # From our new module
     "AWS_REGION"          = var.aws_region
+    "ORDER_EVENTS_TOPIC_ARN" = module.messaging.sns_topic_arn
   }
 }
 
 
-/*
-# --- HW7: Worker Service ---
-# We don't need this for the shopping cart API
-
-# Worker Service ECR Repo
-module "ecr_worker" {
-  source          = "./modules/ecr"
-  repository_name = var.worker_ecr_repository_name
-}
+# --- Order Worker Service ---
+# Same image as the API, started with the /order-worker entrypoint (consumes the order-processing-queue)
 
 # Worker Service Logging
 module "logging_worker" {
   source            = "./modules/logging"
-  service_name      = "async-worker" # New name for CloudWatch
+  service_name      = "order-worker" # New name for CloudWatch
   retention_in_days = var.log_retention_days
 }
 
 # Worker Service ECS Definition
 module "ecs_worker" {
   source             = "./modules/ecs"
-  service_name       = "async-worker"
-  image              = docker_registry_image.worker_app.name
+  service_name       = "order-worker"
+  image              = docker_registry_image.app.name
+  entry_point        = ["/order-worker"]
   container_port     = var.container_port # Not used, but required by module
   subnet_ids         = module.network.private_subnet_ids
   security_group_ids = [module.network.ecs_security_group_id]
   execution_role_arn = data.aws_iam_role.lab_role.arn
-  task_role_arn      = data.aws_iam_role.lab_role.arn # MODIFIED: Use LabRole
+  task_role_arn      = data.aws_iam_role.lab_role.arn # LabRole can read the queue and write the table
   log_group_name     = module.logging_worker.log_group_name
   region             = var.aws_region
-  desired_count      = 1 # Requirement: Start with 1 worker
+  desired_count      = 1
 
-  # NEW: Pass the SQS Queue URL
   environment_variables = {
-    "SQS_QUEUE_URL" = module.messaging.sqs_queue_url
+    "DATABASE_TYPE"       = "dynamodb"
+    "DYNAMODB_TABLE_NAME" = module.dynamodb.table_name
+    "AWS_REGION"          = var.aws_region
+    "ORDER_QUEUE_URL"     = module.messaging.sqs_queue_url
   }
 }
-*/
 
 # --- Docker Build/Push ---
 
-# API App (from src/)
+# API App (from the shared onlinestore module, DATABASE_TYPE=dynamodb)
 # This is the *only* app we need to build for HW8
 resource "docker_image" "app" {
   name = "${module.ecr.repository_url}:latest" 
   build {
-    context    = "${path.module}/../../dynamodb-solution/src"
+    context    = "${path.module}/../../onlinestore"
     dockerfile = "Dockerfile"
   }
 }
@@ -23,6 +23,22 @@ resource "aws_dynamodb_table" "shopping_carts" {
     projection_type = "ALL"
   }
 
+  # GSI for finding the carts holding a product (deleting a product removes its cart items)
+  global_secondary_index {
+    name            = "GSI2-ProductIndex"
+    hash_key        = "GSI2PK"
+    range_key       = "GSI2SK"
+    projection_type = "KEYS_ONLY"
+  }
+
+  # GSI holding only the product catalog, read page by page by product search
+  global_secondary_index {
+    name            = "GSI3-CatalogIndex"
+    hash_key        = "GSI3PK"
+    range_key       = "GSI3SK"
+    projection_type = "ALL"
+  }
+
   # GSI key attributes
   attribute {
     name = "GSI1PK"
@@ -32,6 +48,28 @@ resource "aws_dynamodb_table" "shopping_carts" {
     name = "GSI1SK"
     type = "S"
   }
+  attribute {
+    name = "GSI2PK"
+    type = "S"
+  }
+  attribute {
+    name = "GSI2SK"
+    type = "S"
+  }
+  attribute {
+    name = "GSI3PK"
+    type = "S"
+  }
+  attribute {
+    name = "GSI3SK"
+    type = "N"
+  }
+
+  # Idempotency-Key items are deleted by DynamoDB once expires_at (unix seconds) has passed
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
 
   tags = var.tags
 }
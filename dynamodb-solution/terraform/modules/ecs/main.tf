@@ -98,7 +98,8 @@ resource "aws_ecs_service" "this" {
   launch_type     = "FARGATE"
   
 
-  health_check_grace_period_seconds = 30
+  # only valid for services behind a load balancer (the worker has none)
+  health_check_grace_period_seconds = var.target_group_arn != null ? 30 : null
 
   network_configuration {
     subnets         = var.subnet_ids
@@ -126,7 +127,7 @@ locals {
     value = v
   }]
 
-  container_def = jsonencode([{
+  container_def = jsonencode([merge({
     name      = "${var.service_name}-container"
     image     = var.image
     essential = true
@@ -146,7 +147,7 @@ locals {
         "awslogs-stream-prefix" = "ecs"
       }
     }
-  }])
+  }, var.entry_point != null ? { entryPoint = var.entry_point } : {})])
 }
 
 # --- Task Definition ---
@@ -139,3 +139,9 @@ variable "environment_variables" {
   type        = map(string)
   default     = {}
 }
+
+variable "entry_point" {
+  description = "Overrides the ENTRYPOINT of the image (e.g. [\"/order-worker\"]), null keeps the image default"
+  type        = list(string)
+  default     = null
+}
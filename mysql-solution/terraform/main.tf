@@ -57,8 +57,8 @@ resource "docker_image" "app" {
   name = "${module.ecr.repository_url}:latest"
 
   build {
-    # relative path from terraform/ → src/
-    context = "../src"
+    # relative path from terraform/ → the shared onlinestore module
+    context = "../../onlinestore"
     # Dockerfile defaults to "Dockerfile" in that context
   }
 }
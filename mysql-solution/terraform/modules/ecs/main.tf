@@ -33,6 +33,10 @@ resource "aws_ecs_task_definition" "this" {
     }
 
     environment = [
+      {
+        name  = "DATABASE_TYPE"
+        value = "mysql"
+      },
       {
         name  = "DB_HOST"
         value = var.db_instance_address
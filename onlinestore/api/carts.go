@@ -0,0 +1,204 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/events"
+	"hw8-onlinestore/store"
+)
+
+// define update shopping cart structs
+type updateCartItem struct {
+	ProductID int32 `json:"product_id"`
+	Quantity  uint  `json:"quantity"`
+}
+type updateCartItemsRequest struct {
+	Items []updateCartItem `json:"items"`
+}
+
+/* Internal function: ETag of a cart version (strong validator) */
+func cartETag(version uint64) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+/* Internal function: parse the If-Match header into the expected cart version
+ * Returns 0 when the header is absent or "*" (any version), responds 400 and returns false if it is malformed */
+func parseIfMatch(c *gin.Context) (uint64, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, true
+	}
+	version, err := strconv.ParseUint(strings.Trim(ifMatch, "\""), 10, 64)
+	if (err != nil) || (version < 1) {
+		invalidInput(c, "The provided If-Match header is invalid", fmt.Sprintf("If-Match must be an ETag returned by GET /shopping-carts/:id (input: %s)", ifMatch))
+		return 0, false
+	}
+	return version, true
+}
+
+// Shopping cart service endpoints
+/* Creates a new shopping cart and returns the cart ID and initial state
+ * Assumption: Only creates an active shopping cart if the customer does not have an active shopping cart
+ */
+func (s *Server) createShoppingCart(c *gin.Context) {
+	var req struct {
+		CustomerID uint64 `json:"customer_id"`
+	}
+
+	// parse request content
+	if err := c.BindJSON(&req); err != nil {
+		invalidInput(c, "The provided request body is invalid", err.Error())
+		return
+	}
+
+	// check format of customer ID
+	if req.CustomerID == 0 {
+		invalidInput(c, "customer_id cannot be 0", "")
+		return
+	}
+
+	c.Set(logCustomerID, req.CustomerID)
+	cart, err := s.carts.Create(c.Request.Context(), req.CustomerID)
+	if err != nil {
+		respondStoreError(c, err, "Failed to create shopping cart")
+		return
+	}
+	c.Set(logCartID, cart.CartID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"cart_id": cart.CartID,
+		"status":  cart.Status,
+	})
+}
+
+/* Get a shopping cart with its items, the ETag header carries the cart version (for If-Match on updates) */
+func (s *Server) getShoppingCart(c *gin.Context) {
+	cart, err := s.carts.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondStoreError(c, err, "Failed to query shopping cart")
+		return
+	}
+	c.Set(logCustomerID, cart.CustomerID)
+
+	c.Header("ETag", cartETag(cart.Version))
+	c.JSON(http.StatusOK, cart)
+}
+
+/* Add, update or remove items in existing cart (handle product references and quantities)
+ * A quantity of 0 removes the product from the cart
+ * The DynamoDB backend writes an update in one transaction, more than 99 items are rejected with 400 INVALID_INPUT
+ * With an If-Match header the update is rejected with 412 if the cart changed since that ETag was read */
+func (s *Server) updateItemToShoppingCart(c *gin.Context) {
+	cartIDStr := c.Param("id")
+	ifVersion, ok := parseIfMatch(c)
+	if !ok {
+		return
+	}
+
+	// parse the request
+	var req updateCartItemsRequest
+	if err := c.BindJSON(&req); err != nil {
+		invalidInput(c, "The provided request body is invalid", err.Error())
+		return
+	}
+	if len(req.Items) == 0 {
+		invalidInput(c, "The provided request body is invalid", "items must contain at least one item")
+		return
+	}
+
+	// check product IDs, quantities and duplicated products in the request
+	seen := make(map[int32]bool)
+	duplicateProducts := make([]int32, 0)
+	updates := make([]store.ItemUpdate, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item.ProductID < 1 {
+			invalidInput(c, "The provided request body is invalid", fmt.Sprintf("product_id must be an positive integer >= 1 (input: %d)", item.ProductID))
+			return
+		}
+		if seen[item.ProductID] {
+			duplicateProducts = append(duplicateProducts, item.ProductID)
+			continue
+		}
+		seen[item.ProductID] = true
+		updates = append(updates, store.ItemUpdate{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	if len(duplicateProducts) > 0 {
+		invalidInput(c, "Duplicate product_ids in request", fmt.Sprintf("Duplicate product_ids in request: %v", duplicateProducts))
+		return
+	}
+
+	version, err := s.carts.UpsertItems(c.Request.Context(), cartIDStr, updates, ifVersion)
+	if err != nil {
+		respondStoreError(c, err, "Failed to add/update shopping cart items")
+		return
+	}
+
+	// response
+	c.Header("ETag", cartETag(version))
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Cart %s updated", cartIDStr),
+	})
+}
+
+/* Remove a product from a cart (same as updating its quantity to 0), inventory reserved by the item is released
+ * Removing a product that is not in the cart succeeds as well, If-Match is honored like for updates */
+func (s *Server) removeItemFromShoppingCart(c *gin.Context) {
+	productID, ok := parseProductID(c)
+	if !ok {
+		return
+	}
+	ifVersion, ok := parseIfMatch(c)
+	if !ok {
+		return
+	}
+
+	removal := []store.ItemUpdate{{ProductID: productID, Quantity: 0}}
+	version, err := s.carts.UpsertItems(c.Request.Context(), c.Param("id"), removal, ifVersion)
+	if err != nil {
+		respondStoreError(c, err, "Failed to remove shopping cart item")
+		return
+	}
+
+	c.Header("ETag", cartETag(version))
+	c.Status(http.StatusNoContent) // status 204
+}
+
+/* Move a shopping cart to status to (checkout, pay, ship, complete, cancel)
+ * Illegal transitions are rejected with 409 INVALID_TRANSITION
+ * A checkout publishes an OrderPlaced event; the order stays placed if publishing fails (the failure is logged) */
+func (s *Server) transitionShoppingCart(to string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cart, err := s.carts.Transition(c.Request.Context(), c.Param("id"), to)
+		if err != nil {
+			respondStoreError(c, err, "Failed to update shopping cart status")
+			return
+		}
+		c.Set(logCustomerID, cart.CustomerID)
+
+		if to == store.CartStatusOrdered && s.publisher != nil {
+			if err := s.publisher.Publish(c.Request.Context(), events.NewOrderPlaced(cart)); err != nil {
+				slog.ErrorContext(c.Request.Context(), "order placed but its OrderPlaced event was not published", "cart_id", cart.CartID, "error", err)
+			}
+		}
+
+		c.Header("ETag", cartETag(cart.Version))
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+/* Clear data in shopping_carts and cart_itmes tables in the database (keep tables, and product data) */
+func clearCartsData(clearer store.CartClearer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := clearer.ClearCarts(c.Request.Context()); err != nil {
+			respondStoreError(c, err, "Fail to delete data in shopping_carts and cart_itmes tables")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Shopping cart data cleared"})
+	}
+}
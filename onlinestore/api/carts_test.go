@@ -0,0 +1,302 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/store"
+	"hw8-onlinestore/store/memstore"
+)
+
+/* Internal function: router on an in-memory backend holding products 1..3 (USD, price 100 * product_id) */
+func newTestRouter(t *testing.T) (*gin.Engine, *memstore.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	s := memstore.New()
+	products := make([]*store.Product, 0, 3)
+	for id := int32(1); id <= 3; id++ {
+		products = append(products, &store.Product{
+			ID:          id,
+			Name:        "Product",
+			Category:    "Category",
+			Description: "Description",
+			Brand:       "Brand",
+			Price:       int64(100 * id),
+			Currency:    store.DefaultCurrency,
+		})
+	}
+	s.AddProducts(products)
+	return NewRouter(Options{Carts: s, Products: s}), s
+}
+
+/* Internal function: send a request with an optional JSON body and headers (name, value pairs) */
+func doRequest(t *testing.T, router http.Handler, method string, path string, body any, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+/* Internal function: fail the test unless the response has the expected status (and error code if not empty) */
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int, errCode string) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, w.Code, w.Body.String())
+	}
+	if errCode == "" {
+		return
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	if resp.Err != errCode {
+		t.Fatalf("expected error %s, got %s: %s", errCode, resp.Err, resp.Details)
+	}
+}
+
+/* Internal function: create a cart for a customer and return its ID */
+func createCart(t *testing.T, router http.Handler, customerID uint64) string {
+	t.Helper()
+
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts", gin.H{"customer_id": customerID})
+	expectStatus(t, w, http.StatusCreated, "")
+	var resp struct {
+		CartID string `json:"cart_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode cart: %v", err)
+	}
+	return resp.CartID
+}
+
+/* Internal function: get a cart and its ETag */
+func getCart(t *testing.T, router http.Handler, cartID string) (store.Cart, string) {
+	t.Helper()
+
+	w := doRequest(t, router, http.MethodGet, "/shopping-carts/"+cartID, nil)
+	expectStatus(t, w, http.StatusOK, "")
+	var cart store.Cart
+	if err := json.Unmarshal(w.Body.Bytes(), &cart); err != nil {
+		t.Fatalf("failed to decode cart: %v", err)
+	}
+	return cart, w.Header().Get("ETag")
+}
+
+/* Internal function: request body of an item update */
+func itemsBody(items ...updateCartItem) updateCartItemsRequest {
+	return updateCartItemsRequest{Items: items}
+}
+
+func TestCreateCartOneActivePerCustomer(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	cartID := createCart(t, router, 7)
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts", gin.H{"customer_id": 7})
+	expectStatus(t, w, http.StatusConflict, "ACTIVE_CART_EXISTS")
+
+	// another customer is not affected
+	createCart(t, router, 8)
+
+	// once the cart is cancelled the customer can create a new one
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/cancel", nil)
+	expectStatus(t, w, http.StatusOK, "")
+	if newCartID := createCart(t, router, 7); newCartID == cartID {
+		t.Fatalf("expected a new cart, got cart %s again", cartID)
+	}
+}
+
+func TestUpsertUnknownProduct(t *testing.T) {
+	router, _ := newTestRouter(t)
+	cartID := createCart(t, router, 1)
+
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items",
+		itemsBody(updateCartItem{ProductID: 1, Quantity: 1}, updateCartItem{ProductID: 99, Quantity: 1}))
+	expectStatus(t, w, http.StatusNotFound, "PRODUCT_NOT_FOUND")
+
+	// all or nothing: the known product was not added either
+	cart, etag := getCart(t, router, cartID)
+	if len(cart.Items) != 0 {
+		t.Fatalf("expected an empty cart, got %+v", cart.Items)
+	}
+	if etag != cartETag(1) {
+		t.Fatalf("expected ETag %s, got %s", cartETag(1), etag)
+	}
+}
+
+func TestUpsertQuantityZeroRemovesItem(t *testing.T) {
+	router, _ := newTestRouter(t)
+	cartID := createCart(t, router, 1)
+
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items",
+		itemsBody(updateCartItem{ProductID: 1, Quantity: 2}, updateCartItem{ProductID: 2, Quantity: 1}))
+	expectStatus(t, w, http.StatusOK, "")
+
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items", itemsBody(updateCartItem{ProductID: 1, Quantity: 0}))
+	expectStatus(t, w, http.StatusOK, "")
+
+	cart, _ := getCart(t, router, cartID)
+	if len(cart.Items) != 1 || cart.Items[0].ProductID != 2 {
+		t.Fatalf("expected only product 2 in the cart, got %+v", cart.Items)
+	}
+	if cart.ItemCount != 1 || cart.Subtotal != 200 {
+		t.Fatalf("expected item_count 1 and subtotal 200, got %d and %d", cart.ItemCount, cart.Subtotal)
+	}
+
+	// removing a product that is not in the cart succeeds as well
+	w = doRequest(t, router, http.MethodDelete, "/shopping-carts/"+cartID+"/items/3", nil)
+	expectStatus(t, w, http.StatusNoContent, "")
+}
+
+func TestUpsertStaleIfMatch(t *testing.T) {
+	router, _ := newTestRouter(t)
+	cartID := createCart(t, router, 1)
+	_, etag := getCart(t, router, cartID)
+
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items", itemsBody(updateCartItem{ProductID: 1, Quantity: 1}), "If-Match", etag)
+	expectStatus(t, w, http.StatusOK, "")
+	newETag := w.Header().Get("ETag")
+	if newETag == etag {
+		t.Fatalf("expected the ETag to change after an update, still %s", etag)
+	}
+
+	// a second tab still holding the first ETag is rejected without changing the cart
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items", itemsBody(updateCartItem{ProductID: 2, Quantity: 1}), "If-Match", etag)
+	expectStatus(t, w, http.StatusPreconditionFailed, "PRECONDITION_FAILED")
+
+	cart, current := getCart(t, router, cartID)
+	if current != newETag || len(cart.Items) != 1 {
+		t.Fatalf("expected the cart to be unchanged at ETag %s, got ETag %s with items %+v", newETag, current, cart.Items)
+	}
+}
+
+func TestCheckoutEmptyOrInactiveCart(t *testing.T) {
+	router, _ := newTestRouter(t)
+	cartID := createCart(t, router, 1)
+
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/checkout", nil)
+	expectStatus(t, w, http.StatusConflict, "CART_EMPTY")
+	if cart, _ := getCart(t, router, cartID); cart.Status != store.CartStatusActive {
+		t.Fatalf("expected the empty cart to stay active, got '%s'", cart.Status)
+	}
+
+	// a cancelled cart can not be checked out, even with items
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items", itemsBody(updateCartItem{ProductID: 1, Quantity: 1}))
+	expectStatus(t, w, http.StatusOK, "")
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/cancel", nil)
+	expectStatus(t, w, http.StatusOK, "")
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/checkout", nil)
+	expectStatus(t, w, http.StatusConflict, "INVALID_TRANSITION")
+
+	// an ordered cart can not be checked out twice
+	cartID = createCart(t, router, 1)
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items", itemsBody(updateCartItem{ProductID: 1, Quantity: 1}))
+	expectStatus(t, w, http.StatusOK, "")
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/checkout", nil)
+	expectStatus(t, w, http.StatusOK, "")
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/checkout", nil)
+	expectStatus(t, w, http.StatusConflict, "INVALID_TRANSITION")
+}
+
+func TestIdempotencyKeyReplayAndConflict(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	first := doRequest(t, router, http.MethodPost, "/shopping-carts", gin.H{"customer_id": 5}, "Idempotency-Key", "create-5")
+	expectStatus(t, first, http.StatusCreated, "")
+
+	// a retry gets the stored response instead of ACTIVE_CART_EXISTS
+	retry := doRequest(t, router, http.MethodPost, "/shopping-carts", gin.H{"customer_id": 5}, "Idempotency-Key", "create-5")
+	expectStatus(t, retry, http.StatusCreated, "")
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected the Idempotent-Replayed header on the retry")
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the replayed body %s, got %s", first.Body.String(), retry.Body.String())
+	}
+
+	// the same key for a different request is rejected
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts", gin.H{"customer_id": 6}, "Idempotency-Key", "create-5")
+	expectStatus(t, w, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED")
+
+	// item updates replay their ETag, the update is applied once
+	var created struct {
+		CartID string `json:"cart_id"`
+	}
+	if err := json.Unmarshal(first.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode cart: %v", err)
+	}
+	path := "/shopping-carts/" + created.CartID + "/items"
+	update := doRequest(t, router, http.MethodPost, path, itemsBody(updateCartItem{ProductID: 1, Quantity: 1}), "Idempotency-Key", "update-1")
+	expectStatus(t, update, http.StatusOK, "")
+	replayed := doRequest(t, router, http.MethodPost, path, itemsBody(updateCartItem{ProductID: 1, Quantity: 1}), "Idempotency-Key", "update-1")
+	expectStatus(t, replayed, http.StatusOK, "")
+	if replayed.Header().Get("ETag") != update.Header().Get("ETag") {
+		t.Fatalf("expected the replayed ETag %s, got %s", update.Header().Get("ETag"), replayed.Header().Get("ETag"))
+	}
+	if _, etag := getCart(t, router, created.CartID); etag != update.Header().Get("ETag") {
+		t.Fatalf("expected the cart to be updated once (ETag %s), got ETag %s", update.Header().Get("ETag"), etag)
+	}
+
+	// a 409 is not stored: once the conflict is gone a retry with the same key runs again
+	conflict := doRequest(t, router, http.MethodPost, "/shopping-carts", gin.H{"customer_id": 5}, "Idempotency-Key", "create-5-again")
+	expectStatus(t, conflict, http.StatusConflict, "ACTIVE_CART_EXISTS")
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+created.CartID+"/cancel", nil)
+	expectStatus(t, w, http.StatusOK, "")
+	retried := doRequest(t, router, http.MethodPost, "/shopping-carts", gin.H{"customer_id": 5}, "Idempotency-Key", "create-5-again")
+	expectStatus(t, retried, http.StatusCreated, "")
+	if retried.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("expected the retry to run again instead of replaying the 409")
+	}
+}
+
+// panickingCarts panics on Create, on top of an in-memory backend
+type panickingCarts struct {
+	*memstore.Store
+}
+
+func (panickingCarts) Create(ctx context.Context, customerID uint64) (store.Cart, error) {
+	panic("create failed")
+}
+
+func TestIdempotencyKeyReleasedAfterPanic(t *testing.T) {
+	_, s := newTestRouter(t)
+	router := NewRouter(Options{Carts: panickingCarts{s}, Products: s})
+
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts", gin.H{"customer_id": 1}, "Idempotency-Key", "create-1")
+	expectStatus(t, w, http.StatusInternalServerError, "INTERNAL_ERROR")
+
+	// the key was released, so a retry is not left waiting for the lease to expire
+	existing, err := s.ClaimIdempotencyKey(context.Background(), "create-1", "fingerprint", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to claim the key: %v", err)
+	}
+	if existing != nil {
+		t.Fatalf("expected the key to be released after the panic, got %+v", existing)
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/store"
+)
+
+// page size of the cart listing
+const (
+	defaultCartPageSize = 20
+	maxCartPageSize     = 100
+)
+
+// Customer endpoints
+/* List a customer's shopping carts page by page (order history)
+ * 	Query parameters:
+ * 		- status=xxx     only carts in this status
+ * 		- limit=n        page size, 1..100 (default 20)
+ * 		- cursor=xxx     next_cursor returned by the previous page */
+func listCustomerCarts(lister store.CartLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerIDStr := c.Param("id")
+		customerID, err := strconv.ParseUint(customerIDStr, 10, 64)
+		if (err != nil) || (customerID < 1) {
+			invalidInput(c, "The provided customer id is invalid", fmt.Sprintf("customer ID must be an positive integer >= 1 (input: %s)", customerIDStr))
+			return
+		}
+
+		query := store.CartListQuery{
+			CustomerID: customerID,
+			Status:     c.Query("status"),
+			Limit:      defaultCartPageSize,
+			Cursor:     c.Query("cursor"),
+		}
+		if query.Status != "" && !store.IsCartStatus(query.Status) {
+			invalidInput(c, "The provided status is invalid", fmt.Sprintf("unknown shopping cart status '%s'", query.Status))
+			return
+		}
+		if limitStr := c.Query("limit"); limitStr != "" {
+			limit, err := strconv.Atoi(limitStr)
+			if (err != nil) || (limit < 1) || (limit > maxCartPageSize) {
+				invalidInput(c, "The provided limit is invalid", fmt.Sprintf("limit must be an integer between 1 and %d (input: %s)", maxCartPageSize, limitStr))
+				return
+			}
+			query.Limit = limit
+		}
+
+		page, err := lister.ListCarts(c.Request.Context(), query)
+		if err != nil {
+			respondStoreError(c, err, "Failed to list shopping carts")
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
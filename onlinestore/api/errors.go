@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/logging"
+	"hw8-onlinestore/store"
+)
+
+// define Error struct
+type ErrorResponse struct {
+	Err       string `json:"error"`
+	Message   string `json:"message"`
+	Details   string `json:"details"`
+	RequestID string `json:"request_id,omitempty"` // filled by respondError
+}
+
+/* Internal function: respond with an ErrorResponse carrying the request ID, and stop the handler chain
+ * (the error code is added to the request log) */
+func respondError(c *gin.Context, status int, response ErrorResponse) {
+	response.RequestID = logging.RequestID(c.Request.Context())
+	c.Set(logErrorCode, response.Err)
+	c.AbortWithStatusJSON(status, response)
+}
+
+/* Internal function: respond with 400 INVALID_INPUT */
+func invalidInput(c *gin.Context, message string, details string) {
+	respondError(c, http.StatusBadRequest, ErrorResponse{
+		Err:     "INVALID_INPUT",
+		Message: message,
+		Details: details,
+	}) // status 400 + Error
+}
+
+/* Internal function: map an error returned by a store to the matching status code and ErrorResponse
+ * message is used for errors that are not known store errors (status 500) */
+func respondStoreError(c *gin.Context, err error, message string) {
+	var activeCart *store.ActiveCartExistsError
+	var insufficientStock *store.InsufficientStockError
+	var invalidTransition *store.InvalidTransitionError
+	var partialWrite *store.PartialWriteError
+
+	switch {
+	case errors.Is(err, store.ErrInvalidCartID):
+		invalidInput(c, "The provided shopping cart id is invalid", err.Error())
+	case errors.Is(err, store.ErrTooManyItems):
+		invalidInput(c, "The provided request body is invalid", err.Error())
+	case errors.Is(err, store.ErrInvalidCursor):
+		invalidInput(c, "The provided cursor is invalid", err.Error())
+	case errors.As(err, &activeCart):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "ACTIVE_CART_EXISTS",
+			Message: "Active cart already exists",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.Is(err, store.ErrCartNotFound):
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Err:     "CART_NOT_FOUND",
+			Message: "Shopping cart not found",
+			Details: err.Error(),
+		}) // status 404 + Error
+	case errors.Is(err, store.ErrProductNotFound):
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Err:     "PRODUCT_NOT_FOUND",
+			Message: "Product not found",
+			Details: err.Error(),
+		}) // status 404 + Error
+	case errors.Is(err, store.ErrProductInUse):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "PRODUCT_IN_USE",
+			Message: "Product is part of an order and can not be deleted",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.Is(err, store.ErrCurrencyMismatch):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "CURRENCY_MISMATCH",
+			Message: "Product is not priced in the shopping cart currency",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.Is(err, store.ErrVersionMismatch):
+		respondError(c, http.StatusPreconditionFailed, ErrorResponse{
+			Err:     "PRECONDITION_FAILED",
+			Message: "Shopping cart was changed by another request",
+			Details: err.Error(),
+		}) // status 412 + Error
+	case errors.Is(err, store.ErrConcurrentUpdate):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "CONCURRENT_UPDATE",
+			Message: "Shopping cart is being changed by other requests, please retry",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.As(err, &invalidTransition):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "INVALID_TRANSITION",
+			Message: "Shopping cart status can not be changed",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.Is(err, store.ErrCartNotActive):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "CART_NOT_ACTIVE",
+			Message: "Shopping cart is not active",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.Is(err, store.ErrCartEmpty):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "CART_EMPTY",
+			Message: "Shopping cart has no items",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.Is(err, store.ErrInventoryNotFound):
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Err:     "INVENTORY_NOT_FOUND",
+			Message: "Inventory not found",
+			Details: err.Error(),
+		}) // status 404 + Error
+	case errors.As(err, &insufficientStock):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "INSUFFICIENT_STOCK",
+			Message: "Not enough stock to reserve the requested quantity",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.Is(err, store.ErrStockBelowReserved):
+		respondError(c, http.StatusConflict, ErrorResponse{
+			Err:     "STOCK_BELOW_RESERVED",
+			Message: "Stock can not be lower than the reserved quantity",
+			Details: err.Error(),
+		}) // status 409 + Error
+	case errors.As(err, &partialWrite):
+		respondError(c, http.StatusServiceUnavailable, ErrorResponse{
+			Err:     "PARTIAL_WRITE",
+			Message: "Only part of the update was saved, please retry the request",
+			Details: err.Error(),
+		}) // status 503 + Error
+	default:
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Err:     "DB_ERROR",
+			Message: message,
+			Details: err.Error(),
+		})
+	}
+}
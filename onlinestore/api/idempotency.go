@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/store"
+)
+
+// Idempotency-Key handling
+const (
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	idempotencyLease        = 30 * time.Second // how long a key stays claimed by a request that never finishes
+	idempotencyPollInterval = 100 * time.Millisecond
+	maxIdempotencyKeyLength = 255
+)
+
+// headers replayed with a stored response
+var idempotentHeaders = []string{"Content-Type", "ETag"}
+
+/* Internal function: middleware replaying the first response of requests sent with the same Idempotency-Key
+ *  - the first request claims the key, its response is stored for the TTL if it is final: after a 5xx, a 409 conflict or a 412
+ *    (or a handler panic) the key is released, since the same request may succeed when it is retried
+ *  - a retry gets the stored response with the Idempotent-Replayed: true header
+ *  - a duplicate arriving while the first request is running waits for its response (409 IDEMPOTENCY_IN_PROGRESS if it takes too long)
+ *  - reusing a key for a different request is rejected with 422 IDEMPOTENCY_KEY_REUSED
+ * Requests without the header are not affected. */
+func (s *Server) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || s.idempotency == nil {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			invalidInput(c, "The provided Idempotency-Key is invalid", fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+			c.Abort()
+			return
+		}
+
+		// fingerprint of the request: method, path and body (the body is restored for the handler)
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			invalidInput(c, "The provided request body is invalid", err.Error())
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.New()
+		fmt.Fprintf(hash, "%s %s\n", c.Request.Method, c.Request.URL.Path)
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		ctx := c.Request.Context()
+		deadline := time.Now().Add(idempotencyLease)
+		for {
+			existing, err := s.idempotency.ClaimIdempotencyKey(ctx, key, fingerprint, idempotencyLease)
+			if err != nil {
+				respondStoreError(c, err, "Failed to check the Idempotency-Key")
+				c.Abort()
+				return
+			}
+			if existing == nil {
+				break // claimed, run the handler
+			}
+			if existing.Fingerprint != fingerprint {
+				respondError(c, http.StatusUnprocessableEntity, ErrorResponse{
+					Err:     "IDEMPOTENCY_KEY_REUSED",
+					Message: "Idempotency-Key was already used for a different request",
+					Details: fmt.Sprintf("Idempotency-Key: %s", key),
+				}) // status 422 + Error
+				return
+			}
+			if existing.StatusCode != 0 {
+				replay(c, existing)
+				return
+			}
+
+			// concurrent duplicate: wait for the first request to finish
+			if time.Now().After(deadline) {
+				respondError(c, http.StatusConflict, ErrorResponse{
+					Err:     "IDEMPOTENCY_IN_PROGRESS",
+					Message: "A request with the same Idempotency-Key is still being processed",
+					Details: fmt.Sprintf("Idempotency-Key: %s", key),
+				}) // status 409 + Error
+				return
+			}
+			select {
+			case <-ctx.Done():
+				c.Abort()
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+
+		// store the outcome even if the client went away meanwhile
+		ctx = context.WithoutCancel(ctx)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				s.releaseIdempotencyKey(ctx, key)
+				panic(recovered) // recoverPanics responds with 500
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if !finalStatus(status) {
+			s.releaseIdempotencyKey(ctx, key)
+			return
+		}
+
+		resp := store.IdempotentResponse{
+			Fingerprint: fingerprint,
+			StatusCode:  status,
+			Headers:     make(map[string]string),
+			Body:        recorder.body.Bytes(),
+		}
+		for _, name := range idempotentHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				resp.Headers[name] = value
+			}
+		}
+		if err := s.idempotency.SaveIdempotentResponse(ctx, key, resp, s.idempotencyTTL); err != nil {
+			slog.ErrorContext(ctx, "failed to store the response of Idempotency-Key", "idempotency_key", key, "error", err)
+		}
+	}
+}
+
+/* Internal function: whether a response is the final outcome of a request
+ * 5xx, 409 (conflicts such as CONCURRENT_UPDATE or ACTIVE_CART_EXISTS) and 412 (If-Match races) depend on
+ * the current state of the cart, so a retry with the same Idempotency-Key runs the request again */
+func finalStatus(status int) bool {
+	return status < http.StatusInternalServerError && status != http.StatusConflict && status != http.StatusPreconditionFailed
+}
+
+/* Internal function: release a claimed Idempotency-Key, errors are only logged */
+func (s *Server) releaseIdempotencyKey(ctx context.Context, key string) {
+	if err := s.idempotency.ReleaseIdempotencyKey(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "failed to release Idempotency-Key", "idempotency_key", key, "error", err)
+	}
+}
+
+/* Internal function: write a stored response */
+func replay(c *gin.Context, resp *store.IdempotentResponse) {
+	for name, value := range resp.Headers {
+		c.Header(name, value)
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Status(resp.StatusCode)
+	c.Writer.Write(resp.Body)
+	c.Abort()
+}
+
+// responseRecorder keeps a copy of the response body written by the handler
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"hw8-onlinestore/store"
+)
+
+// products stored per ImportProducts call (one transaction / BatchWriteItem sequence)
+const importBatchSize = 500
+
+// longest NDJSON line accepted by the import
+const maxImportLineSize = 1 << 20
+
+// productReader returns the next product record of an import body and its line number, io.EOF at the end
+type productReader interface {
+	next() (newProductRequest, int, error)
+}
+
+/* Bulk import: stream new products from the request body into the catalog
+ * Content-Type application/x-ndjson (one product object per line) or text/csv (header row with
+ * name, category, brand and description columns, price and currency are optional). Products are stored in batches of 500 as they are read,
+ * so an invalid record stops the import after the batches before it (the response tells how many were imported). */
+func (s *Server) importProducts(c *gin.Context) {
+	var reader productReader
+	switch c.ContentType() {
+	case "application/x-ndjson", "application/ndjson":
+		reader = newNDJSONProductReader(c.Request.Body)
+	case "text/csv":
+		csvReader, err := newCSVProductReader(c.Request.Body)
+		if err != nil {
+			invalidInput(c, "The provided CSV header is invalid", err.Error())
+			return
+		}
+		reader = csvReader
+	default:
+		respondError(c, http.StatusUnsupportedMediaType, ErrorResponse{
+			Err:     "UNSUPPORTED_MEDIA_TYPE",
+			Message: "The import body must be NDJSON or CSV",
+			Details: fmt.Sprintf("Content-Type must be application/x-ndjson or text/csv (input: %s)", c.ContentType()),
+		}) // status 415 + Error
+		return
+	}
+
+	imported := 0
+	batch := make([]*store.Product, 0, importBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.catalog.ImportProducts(c.Request.Context(), batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = make([]*store.Product, 0, importBatchSize)
+		return nil
+	}
+
+	for {
+		req, line, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&req)
+		}
+		if err != nil {
+			invalidInput(c, "The provided product record is invalid", fmt.Sprintf("line %d: %v (%d products imported before it)", line, err, imported))
+			return
+		}
+
+		p := req.toProduct()
+		batch = append(batch, &p)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				slog.ErrorContext(c.Request.Context(), "product import failed", "imported", imported, "error", err)
+				respondStoreError(c, err, fmt.Sprintf("Failed to import products (%d products imported)", imported))
+				return
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		slog.ErrorContext(c.Request.Context(), "product import failed", "imported", imported, "error", err)
+		respondStoreError(c, err, fmt.Sprintf("Failed to import products (%d products imported)", imported))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": imported})
+}
+
+// define NDJSON reader struct
+type ndjsonProductReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONProductReader(body io.Reader) *ndjsonProductReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	return &ndjsonProductReader{scanner: scanner}
+}
+
+/* Internal function: next non-blank line decoded as a product */
+func (r *ndjsonProductReader) next() (newProductRequest, int, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.scanner.Text())
+		if text == "" {
+			continue
+		}
+		var req newProductRequest
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			return newProductRequest{}, r.line, err
+		}
+		return req, r.line, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return newProductRequest{}, r.line + 1, err
+	}
+	return newProductRequest{}, r.line, io.EOF
+}
+
+// define CSV reader struct (columns maps a field name to its index in a record)
+type csvProductReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// columns of a CSV import
+var (
+	csvProductColumns  = []string{"name", "category", "brand", "description"}
+	csvOptionalColumns = []string{"price", "currency"}
+)
+
+/* Internal function: read the header row, every column of csvProductColumns must appear once (in any order),
+ * the columns of csvOptionalColumns may appear once */
+func newCSVProductReader(body io.Reader) (*csvProductReader, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the body is empty")
+	} else if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column '%s'", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range csvProductColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column '%s' (columns: %s)", name, strings.Join(csvProductColumns, ", "))
+		}
+	}
+	for name := range columns {
+		if !slices.Contains(csvProductColumns, name) && !slices.Contains(csvOptionalColumns, name) {
+			return nil, fmt.Errorf("unknown column '%s' (columns: %s, optional: %s)", name, strings.Join(csvProductColumns, ", "), strings.Join(csvOptionalColumns, ", "))
+		}
+	}
+	return &csvProductReader{reader: reader, columns: columns}, nil
+}
+
+/* Internal function: next CSV record as a product */
+func (r *csvProductReader) next() (newProductRequest, int, error) {
+	record, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return newProductRequest{}, parseErr.Line, parseErr.Err
+	} else if err != nil {
+		return newProductRequest{}, 0, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	req := newProductRequest{
+		Name:        record[r.columns["name"]],
+		Category:    record[r.columns["category"]],
+		Brand:       record[r.columns["brand"]],
+		Description: record[r.columns["description"]],
+	}
+	if i, ok := r.columns["price"]; ok && record[i] != "" {
+		price, err := strconv.ParseInt(record[i], 10, 64)
+		if err != nil {
+			return newProductRequest{}, line, fmt.Errorf("price must be an integer in minor units (input: %s)", record[i])
+		}
+		req.Price = price
+	}
+	if i, ok := r.columns["currency"]; ok {
+		req.Currency = record[i]
+	}
+	return req, line, nil
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// define update inventory struct
+type setStockRequest struct {
+	Stock *uint `json:"stock"`
+}
+
+/* Internal function: parse the productId path parameter, responds 400 and returns false if it is invalid */
+func parseProductID(c *gin.Context) (int32, bool) {
+	productIDStr := c.Param("productId")
+	productID, err := strconv.ParseInt(productIDStr, 10, 32)
+	if (err != nil) || (productID < 1) {
+		invalidInput(c, "The provided input product ID is invalid", fmt.Sprintf("Product ID must be an positive integer >= 1 (input: %s)", productIDStr))
+		return 0, false
+	}
+	return int32(productID), true
+}
+
+// Inventory endpoints
+/* Get the stock, reserved and available quantities of a product */
+func (s *Server) getInventory(c *gin.Context) {
+	productID, ok := parseProductID(c)
+	if !ok {
+		return
+	}
+
+	inv, err := s.inventory.GetInventory(c.Request.Context(), productID)
+	if err != nil {
+		respondStoreError(c, err, "Failed to query inventory")
+		return
+	}
+	c.JSON(http.StatusOK, inv)
+}
+
+/* Set the stock of a product (creates its inventory record if the product is not stock-managed yet) */
+func (s *Server) setInventory(c *gin.Context) {
+	productID, ok := parseProductID(c)
+	if !ok {
+		return
+	}
+
+	var req setStockRequest
+	if err := c.BindJSON(&req); err != nil {
+		invalidInput(c, "The provided request body is invalid", err.Error())
+		return
+	}
+	if req.Stock == nil {
+		invalidInput(c, "The provided request body is invalid", "stock is required")
+		return
+	}
+
+	inv, err := s.inventory.SetStock(c.Request.Context(), productID, *req.Stock)
+	if err != nil {
+		respondStoreError(c, err, "Failed to update inventory")
+		return
+	}
+	c.JSON(http.StatusOK, inv)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/store"
+)
+
+/* Internal function: get the inventory of a product */
+func getInventory(t *testing.T, router http.Handler, path string) store.Inventory {
+	t.Helper()
+
+	w := doRequest(t, router, http.MethodGet, path, nil)
+	expectStatus(t, w, http.StatusOK, "")
+	var inv store.Inventory
+	if err := json.Unmarshal(w.Body.Bytes(), &inv); err != nil {
+		t.Fatalf("failed to decode inventory: %v", err)
+	}
+	return inv
+}
+
+func TestSetStockReservesItemsAlreadyInCarts(t *testing.T) {
+	router, _ := newTestRouter(t)
+	cartID := createCart(t, router, 1)
+
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items", itemsBody(updateCartItem{ProductID: 3, Quantity: 2}))
+	expectStatus(t, w, http.StatusOK, "")
+
+	// the product becomes stock-managed while it is in an active cart
+	w = doRequest(t, router, http.MethodPut, "/inventory/3", gin.H{"stock": 10})
+	expectStatus(t, w, http.StatusOK, "")
+	if inv := getInventory(t, router, "/inventory/3"); inv.Reserved != 2 || inv.Available != 8 {
+		t.Fatalf("expected reserved 2 and available 8, got %+v", inv)
+	}
+
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items", itemsBody(updateCartItem{ProductID: 3, Quantity: 1}))
+	expectStatus(t, w, http.StatusOK, "")
+	if inv := getInventory(t, router, "/inventory/3"); inv.Reserved != 1 || inv.Available != 9 {
+		t.Fatalf("expected reserved 1 and available 9, got %+v", inv)
+	}
+
+	// stock can not go below what the carts already hold
+	w = doRequest(t, router, http.MethodPut, "/inventory/3", gin.H{"stock": 0})
+	expectStatus(t, w, http.StatusConflict, "STOCK_BELOW_RESERVED")
+
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/cancel", nil)
+	expectStatus(t, w, http.StatusOK, "")
+	if inv := getInventory(t, router, "/inventory/3"); inv.Reserved != 0 || inv.Available != 10 {
+		t.Fatalf("expected reserved 0 and available 10, got %+v", inv)
+	}
+}
+
+func TestUpsertInsufficientStock(t *testing.T) {
+	router, _ := newTestRouter(t)
+	w := doRequest(t, router, http.MethodPut, "/inventory/1", gin.H{"stock": 3})
+	expectStatus(t, w, http.StatusOK, "")
+
+	first := createCart(t, router, 1)
+	second := createCart(t, router, 2)
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+first+"/items", itemsBody(updateCartItem{ProductID: 1, Quantity: 2}))
+	expectStatus(t, w, http.StatusOK, "")
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+second+"/items", itemsBody(updateCartItem{ProductID: 1, Quantity: 2}))
+	expectStatus(t, w, http.StatusConflict, "INSUFFICIENT_STOCK")
+
+	// the cart holding the reservation can still grow up to the stock
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+first+"/items", itemsBody(updateCartItem{ProductID: 1, Quantity: 3}))
+	expectStatus(t, w, http.StatusOK, "")
+	if inv := getInventory(t, router, "/inventory/1"); inv.Reserved != 3 || inv.Available != 0 {
+		t.Fatalf("expected reserved 3 and available 0, got %+v", inv)
+	}
+}
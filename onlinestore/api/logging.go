@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"hw8-onlinestore/logging"
+)
+
+// header carrying the request ID, accepted from the client (or the load balancer) and always returned
+const requestIDHeader = "X-Request-ID"
+
+// accepted client request IDs, anything else is replaced by a generated UUID
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// gin context keys of the fields handlers add to the request log
+const (
+	logCartID     = "log_cart_id"
+	logCustomerID = "log_customer_id"
+	logErrorCode  = "log_error_code"
+)
+
+/* Internal function: middleware giving every request an ID (X-Request-ID header of the request, or a new UUID)
+ * The ID is returned in the X-Request-ID response header, carried by the request context for the logs
+ * and included in every ErrorResponse. */
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+/* Internal function: middleware writing one JSON log line per request, replacing gin's text logger
+ * Fields: request_id, method, route, path, status, latency_ms, db_ms and db_calls (time spent in database calls),
+ * plus cart_id, customer_id and error_code when they are known. 5xx are logged at level ERROR, 4xx at WARN. */
+func logRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		status := c.Writer.Status()
+		dbTime, dbCalls := logging.DBTime(c.Request.Context())
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Float64("db_ms", float64(dbTime.Microseconds())/1000),
+			slog.Int("db_calls", dbCalls),
+			slog.String("client_ip", c.ClientIP()),
+		}
+
+		// cart and customer IDs: set by the handler, or taken from the route parameters
+		cartID := c.GetString(logCartID)
+		if cartID == "" && strings.HasPrefix(route, "/shopping-carts/:id") {
+			cartID = c.Param("id")
+		}
+		if cartID != "" {
+			attrs = append(attrs, slog.String("cart_id", cartID))
+		}
+		if customerID, ok := c.Get(logCustomerID); ok {
+			attrs = append(attrs, slog.String("customer_id", fmt.Sprint(customerID)))
+		} else if strings.HasPrefix(route, "/customers/:id") {
+			attrs = append(attrs, slog.String("customer_id", c.Param("id")))
+		}
+		if code := c.GetString(logErrorCode); code != "" {
+			attrs = append(attrs, slog.String("error_code", code))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+/* Internal function: middleware turning a handler panic into 500 INTERNAL_ERROR, logged with the panic value */
+func recoverPanics() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "handler panic", "panic", fmt.Sprint(recovered), "route", c.FullPath())
+		respondError(c, http.StatusInternalServerError, ErrorResponse{
+			Err:     "INTERNAL_ERROR",
+			Message: "Unexpected server error",
+			Details: "",
+		})
+		c.Abort()
+	})
+}
+
+/* Internal function: gin's debug output (route table and warnings, in debug mode only) as DEBUG log records */
+func logGinDebug(format string, values ...any) {
+	slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)), "component", "gin")
+}
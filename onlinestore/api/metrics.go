@@ -0,0 +1,24 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/metrics"
+)
+
+/* Internal function: middleware recording the count and latency of every request by method, route and status
+ * The route is the registered pattern (/shopping-carts/:id), requests matching no route are recorded as "unmatched". */
+func observeRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/store"
+)
+
+// Product service endpoints
+/* Get product by ID: retrieve a product's details using its unique identifier */
+func (s *Server) getProduct(c *gin.Context) {
+	// retrieve productID from the path
+	productIDStr := c.Param("productId")
+
+	// check input productID validation
+	productID, err := strconv.ParseInt(productIDStr, 10, 32)
+	if (err != nil) || (productID < 1) {
+		respondError(c, http.StatusNotFound, ErrorResponse{
+			Err:     "PRODUCT_NOT_FOUND",
+			Message: "Product not found",
+			Details: fmt.Sprintf("Invalid input: product ID must be an positive integer >= 1 (input: %s)", productIDStr),
+		}) // status 404 + Error
+		return
+	}
+
+	// look for Product by productID in database
+	p, err := s.products.GetProduct(c.Request.Context(), int32(productID))
+	if err != nil {
+		respondStoreError(c, err, "Fail to query product in the database")
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+/* Add product details: add or update detailed information for a specific product
+ * Assumption: I assume that this POST request updates a product if it exists, and returns 404 if the specified product ID does not exist.
+ * price and currency are optional, the product keeps its stored price and currency when they are omitted */
+func (s *Server) addProductDetails(c *gin.Context) {
+	// retrieve productID from the path
+	productIDStr := c.Param("productId")
+
+	// check productID validation
+	productID, err := strconv.ParseInt(productIDStr, 10, 32)
+	if (err != nil) || (productID < 1) {
+		invalidInput(c, "The provided input product ID is invalid", fmt.Sprintf("Product ID must be an positive integer >= 1 (input: %s)", productIDStr))
+		return
+	}
+	productIDInt32 := int32(productID)
+
+	// retrieve request body
+	var newProductDetails store.ProductUpdate
+	if err := c.BindJSON(&newProductDetails); err != nil {
+		invalidInput(c, "The provided request body is invalid", err.Error())
+		return
+	}
+
+	// check request body
+	if newProductDetails.ID != productIDInt32 {
+		invalidInput(c, "The provided request body is invalid", "The product_id in the request body is different from product_id indicated in the path")
+		return
+	}
+
+	if err := s.products.UpdateProduct(c.Request.Context(), newProductDetails); err != nil {
+		respondStoreError(c, err, "Failed to update product in the database")
+		return
+	}
+
+	c.Status(http.StatusNoContent) // status 204
+}
+
+// define new product struct (product_id is assigned by the backend, currency defaults to store.DefaultCurrency)
+type newProductRequest struct {
+	Name        string `json:"name" binding:"required,min=1"`
+	Category    string `json:"category" binding:"required,min=1"`
+	Description string `json:"description" binding:"required,min=1"`
+	Brand       string `json:"brand" binding:"required,min=1"`
+	Price       int64  `json:"price" binding:"gte=0"`
+	Currency    string `json:"currency" binding:"omitempty,iso4217"`
+}
+
+/* Internal function: product of a validated request */
+func (r newProductRequest) toProduct() store.Product {
+	p := store.Product{Name: r.Name, Category: r.Category, Description: r.Description, Brand: r.Brand, Price: r.Price, Currency: r.Currency}
+	if p.Currency == "" {
+		p.Currency = store.DefaultCurrency
+	}
+	return p
+}
+
+/* Create product: add a new product to the catalog and return it with its product_id */
+func (s *Server) createProduct(c *gin.Context) {
+	var req newProductRequest
+	if err := c.BindJSON(&req); err != nil {
+		invalidInput(c, "The provided request body is invalid", err.Error())
+		return
+	}
+
+	p, err := s.catalog.CreateProduct(c.Request.Context(), req.toProduct())
+	if err != nil {
+		respondStoreError(c, err, "Failed to create product in the database")
+		return
+	}
+	c.JSON(http.StatusCreated, p) // status 201
+}
+
+/* Delete product: remove a product from the catalog
+ * Assumption: products in placed orders (ordered, paid, shipped or completed carts) can not be deleted (409 PRODUCT_IN_USE),
+ * active, cancelled and invalid carts lose the product like with the ON DELETE CASCADE of cart_item */
+func (s *Server) deleteProduct(c *gin.Context) {
+	productID, ok := parseProductID(c)
+	if !ok {
+		return
+	}
+
+	if err := s.catalog.DeleteProduct(c.Request.Context(), productID); err != nil {
+		respondStoreError(c, err, "Failed to delete product in the database")
+		return
+	}
+	c.Status(http.StatusNoContent) // status 204
+}
+
+// page size of the product search
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+/* Search: search products by name, category, brand and description based on queries
+ * 	Search criteria:
+ * 		- /products/search                       no search criteria (all products)
+ * 		- /products/search?q=xxx                 every word of q must match (word prefix), most relevant first
+ * 		- category=xxx, brand=xxx                exact filters (case-insensitive)
+ * 		- sort=relevance|name|id                 result order (default relevance, product_id order without q)
+ * 		- page=n, page_size=n                    pagination (default page 1 of 20 products, page_size <= 100)
+ *   	the response has facet counts per category (ignoring the category filter) and per brand (ignoring the brand filter)
+ *   	note: any other query parameter will be ignored */
+func (s *Server) search(c *gin.Context) {
+	query := store.SearchQuery{
+		Query:    c.Query("q"),
+		Category: c.Query("category"),
+		Brand:    c.Query("brand"),
+		Sort:     c.DefaultQuery("sort", store.SortRelevance),
+		Page:     1,
+		PageSize: defaultSearchPageSize,
+	}
+	if !store.IsSearchSort(query.Sort) {
+		invalidInput(c, "The provided sort is invalid", fmt.Sprintf("sort must be one of %s, %s, %s (input: %s)", store.SortRelevance, store.SortName, store.SortID, query.Sort))
+		return
+	}
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if (err != nil) || (page < 1) {
+			invalidInput(c, "The provided page is invalid", fmt.Sprintf("page must be an positive integer >= 1 (input: %s)", pageStr))
+			return
+		}
+		query.Page = page
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if (err != nil) || (pageSize < 1) || (pageSize > maxSearchPageSize) {
+			invalidInput(c, "The provided page_size is invalid", fmt.Sprintf("page_size must be an integer between 1 and %d (input: %s)", maxSearchPageSize, pageSizeStr))
+			return
+		}
+		query.PageSize = pageSize
+	}
+
+	response, err := s.products.Search(c.Request.Context(), query)
+	if err != nil {
+		respondStoreError(c, err, "Failed to search products")
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/store"
+)
+
+/* Internal function: get a product through the API */
+func getProduct(t *testing.T, router http.Handler, productID string) store.Product {
+	t.Helper()
+
+	w := doRequest(t, router, http.MethodGet, "/products/"+productID, nil)
+	expectStatus(t, w, http.StatusOK, "")
+	var p store.Product
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("failed to decode product: %v", err)
+	}
+	return p
+}
+
+/* Internal function: send a request with a raw body of the given Content-Type */
+func doRawRequest(router http.Handler, method string, path string, contentType string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateProduct(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doRequest(t, router, http.MethodPost, "/products", gin.H{"name": "Lamp", "category": "Home", "brand": "Brand", "description": "Desk lamp", "price": 1999})
+	expectStatus(t, w, http.StatusCreated, "")
+	var created store.Product
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode product: %v", err)
+	}
+	if created.ID != 4 || created.Currency != store.DefaultCurrency {
+		t.Fatalf("expected product 4 in %s, got %+v", store.DefaultCurrency, created)
+	}
+	if p := getProduct(t, router, "4"); p.Name != "Lamp" || p.Price != 1999 {
+		t.Fatalf("expected the new product to be stored, got %+v", p)
+	}
+
+	w = doRequest(t, router, http.MethodPost, "/products", gin.H{"category": "Home", "brand": "Brand", "description": "No name"})
+	expectStatus(t, w, http.StatusBadRequest, "INVALID_INPUT")
+}
+
+func TestDeleteProductInOrder(t *testing.T) {
+	router, _ := newTestRouter(t)
+	cartID := createCart(t, router, 1)
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items", itemsBody(updateCartItem{ProductID: 1, Quantity: 1}))
+	expectStatus(t, w, http.StatusOK, "")
+	w = doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/checkout", nil)
+	expectStatus(t, w, http.StatusOK, "")
+
+	w = doRequest(t, router, http.MethodDelete, "/products/1", nil)
+	expectStatus(t, w, http.StatusConflict, "PRODUCT_IN_USE")
+	getProduct(t, router, "1")
+}
+
+func TestDeleteProductFromCarts(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	// one active and one cancelled cart hold product 2
+	activeID := createCart(t, router, 1)
+	cancelledID := createCart(t, router, 2)
+	for _, cartID := range []string{activeID, cancelledID} {
+		w := doRequest(t, router, http.MethodPost, "/shopping-carts/"+cartID+"/items",
+			itemsBody(updateCartItem{ProductID: 1, Quantity: 1}, updateCartItem{ProductID: 2, Quantity: 3}))
+		expectStatus(t, w, http.StatusOK, "")
+	}
+	w := doRequest(t, router, http.MethodPost, "/shopping-carts/"+cancelledID+"/cancel", nil)
+	expectStatus(t, w, http.StatusOK, "")
+	_, activeETag := getCart(t, router, activeID)
+
+	w = doRequest(t, router, http.MethodDelete, "/products/2", nil)
+	expectStatus(t, w, http.StatusNoContent, "")
+	w = doRequest(t, router, http.MethodGet, "/products/2", nil)
+	expectStatus(t, w, http.StatusNotFound, "PRODUCT_NOT_FOUND")
+
+	// both carts lost the product, their version changed
+	for _, cartID := range []string{activeID, cancelledID} {
+		cart, _ := getCart(t, router, cartID)
+		if len(cart.Items) != 1 || cart.Items[0].ProductID != 1 || cart.Subtotal != 100 {
+			t.Fatalf("expected only product 1 in cart %s, got %+v", cartID, cart.Items)
+		}
+	}
+	if _, etag := getCart(t, router, activeID); etag == activeETag {
+		t.Fatalf("expected the ETag of cart %s to change, still %s", activeID, etag)
+	}
+
+	// a product in no cart is deleted once
+	w = doRequest(t, router, http.MethodDelete, "/products/3", nil)
+	expectStatus(t, w, http.StatusNoContent, "")
+	w = doRequest(t, router, http.MethodDelete, "/products/3", nil)
+	expectStatus(t, w, http.StatusNotFound, "PRODUCT_NOT_FOUND")
+}
+
+func TestImportProducts(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	csvBody := "name,category,brand,description,price\nLamp,Home,Brand,Desk lamp,1999\nChair,Home,Brand,Office chair,\n"
+	w := doRawRequest(router, http.MethodPost, "/products/import", "text/csv", csvBody)
+	expectStatus(t, w, http.StatusOK, "")
+	if !strings.Contains(w.Body.String(), `"imported":2`) {
+		t.Fatalf("expected 2 imported products, got %s", w.Body.String())
+	}
+	if p := getProduct(t, router, "5"); p.Name != "Chair" || p.Price != 0 || p.Currency != store.DefaultCurrency {
+		t.Fatalf("expected product 5 to be the chair, got %+v", p)
+	}
+
+	// a malformed record stops the import and names its line, the records before it are in the same batch
+	ndjsonBody := `{"name":"Desk","category":"Home","brand":"Brand","description":"Desk"}` + "\n\n" +
+		`{"name":"Shelf","category":"Home","brand":"Brand","description":"Shelf","price":-5}` + "\n"
+	w = doRawRequest(router, http.MethodPost, "/products/import", "application/x-ndjson", ndjsonBody)
+	expectStatus(t, w, http.StatusBadRequest, "INVALID_INPUT")
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	if !strings.HasPrefix(resp.Details, "line 3:") || !strings.Contains(resp.Details, "0 products imported") {
+		t.Fatalf("expected the error on line 3 with no product imported, got %s", resp.Details)
+	}
+	w = doRequest(t, router, http.MethodGet, "/products/6", nil)
+	expectStatus(t, w, http.StatusNotFound, "PRODUCT_NOT_FOUND")
+
+	w = doRawRequest(router, http.MethodPost, "/products/import", "text/csv", "name,category,brand,description\nLamp,Home,Brand\n")
+	expectStatus(t, w, http.StatusBadRequest, "INVALID_INPUT")
+}
+
+func TestProductDetailsKeepPrice(t *testing.T) {
+	router, _ := newTestRouter(t)
+	details := gin.H{"product_id": 2, "name": "Renamed", "category": "Category", "description": "Description", "brand": "Brand"}
+
+	// a details update without price and currency keeps both
+	w := doRequest(t, router, http.MethodPost, "/products/2/details", details)
+	expectStatus(t, w, http.StatusNoContent, "")
+	p := getProduct(t, router, "2")
+	if p.Name != "Renamed" || p.Price != 200 || p.Currency != store.DefaultCurrency {
+		t.Fatalf("expected the renamed product to cost 200 USD, got %+v", p)
+	}
+
+	// a price of 0 is an explicit change
+	details["price"] = 0
+	details["currency"] = "EUR"
+	w = doRequest(t, router, http.MethodPost, "/products/2/details", details)
+	expectStatus(t, w, http.StatusNoContent, "")
+	if p := getProduct(t, router, "2"); p.Price != 0 || p.Currency != "EUR" {
+		t.Fatalf("expected the product to cost 0 EUR, got %+v", p)
+	}
+
+	details["price"] = -1
+	w = doRequest(t, router, http.MethodPost, "/products/2/details", details)
+	expectStatus(t, w, http.StatusBadRequest, "INVALID_INPUT")
+}
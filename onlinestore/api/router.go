@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"hw8-onlinestore/events"
+	"hw8-onlinestore/metrics"
+	"hw8-onlinestore/store"
+)
+
+/* Server holds the backends the HTTP handlers talk to */
+type Server struct {
+	carts     store.CartStore
+	products  store.ProductStore   // nil if the backend has no product catalog
+	catalog   store.ProductWriter  // nil if products can not be added or deleted
+	inventory store.InventoryStore // nil if the backend does not track stock
+	publisher events.Publisher     // nil disables order events
+
+	idempotency    store.IdempotencyStore // nil if the backend can not store Idempotency-Key responses
+	idempotencyTTL time.Duration
+}
+
+// define Options struct (dependencies of the router)
+type Options struct {
+	Carts     store.CartStore
+	Products  store.ProductStore
+	Publisher events.Publisher
+
+	IdempotencyTTL time.Duration // how long responses are replayed for an Idempotency-Key (default 24h)
+}
+
+/* NewRouter builds the gin router shared by every backend
+ * Product endpoints are only registered when opts.Products is not nil (create, delete and import
+ * only when it implements store.ProductWriter),
+ * inventory endpoints only when the cart backend implements store.InventoryStore
+ * Idempotency-Key is honored on cart creation and item updates when the backend implements store.IdempotencyStore */
+func NewRouter(opts Options) *gin.Engine {
+	s := &Server{carts: opts.Carts, products: opts.Products, publisher: opts.Publisher, idempotencyTTL: opts.IdempotencyTTL}
+	if catalog, ok := opts.Products.(store.ProductWriter); ok {
+		s.catalog = catalog
+	}
+	if inventory, ok := opts.Carts.(store.InventoryStore); ok {
+		s.inventory = inventory
+	}
+	if idempotency, ok := opts.Carts.(store.IdempotencyStore); ok {
+		s.idempotency = idempotency
+	}
+	if s.idempotencyTTL <= 0 {
+		s.idempotencyTTL = DefaultIdempotencyTTL
+	}
+
+	// Router
+	// (JSON request logs instead of gin's text logger, every request gets an X-Request-ID and a trace span)
+	gin.DebugPrintFunc = logGinDebug
+	router := gin.New()
+	router.Use(requestID(), traceRequests(), logRequests(), observeRequests(), recoverPanics())
+
+	// Product service endpoints
+	if s.products != nil {
+		router.GET("/products/:productId", s.getProduct)
+		router.POST("/products/:productId/details", s.addProductDetails)
+		router.GET("/products/search", s.search)
+	}
+	if s.catalog != nil {
+		router.POST("/products", s.createProduct)
+		router.POST("/products/import", s.importProducts)
+		router.DELETE("/products/:productId", s.deleteProduct)
+	}
+
+	// Inventory endpoints
+	if s.inventory != nil {
+		router.GET("/inventory/:productId", s.getInventory)
+		router.PUT("/inventory/:productId", s.setInventory)
+	}
+
+	// Shopping cart service endpoints
+	router.POST("/shopping-carts", s.idempotent(), s.createShoppingCart)
+	router.GET("/shopping-carts/:id", s.getShoppingCart)
+	router.POST("/shopping-carts/:id/items", s.idempotent(), s.updateItemToShoppingCart)
+	router.DELETE("/shopping-carts/:id/items/:productId", s.idempotent(), s.removeItemFromShoppingCart)
+
+	// Customer endpoints
+	if lister, ok := s.carts.(store.CartLister); ok {
+		router.GET("/customers/:id/shopping-carts", listCustomerCarts(lister))
+	}
+
+	// Shopping cart status lifecycle: active -> ordered -> paid -> shipped -> completed (or cancelled)
+	router.POST("/shopping-carts/:id/checkout", s.transitionShoppingCart(store.CartStatusOrdered))
+	router.POST("/shopping-carts/:id/pay", s.transitionShoppingCart(store.CartStatusPaid))
+	router.POST("/shopping-carts/:id/ship", s.transitionShoppingCart(store.CartStatusShipped))
+	router.POST("/shopping-carts/:id/complete", s.transitionShoppingCart(store.CartStatusCompleted))
+	router.POST("/shopping-carts/:id/cancel", s.transitionShoppingCart(store.CartStatusCancelled))
+
+	// Prometheus metrics endpoint
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Health check endpoint
+	router.GET("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+
+	// Debug endpoints
+	if clearer, ok := s.carts.(store.CartClearer); ok {
+		router.DELETE("/debug/clear-carts", clearCartsData(clearer))
+	}
+
+	return router
+}
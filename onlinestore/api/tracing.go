@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"hw8-onlinestore/logging"
+	"hw8-onlinestore/tracing"
+)
+
+// routes without spans: load balancer health checks and Prometheus scrapes would outnumber the traced requests
+var untracedRoutes = []string{"/health", "/metrics"}
+
+/* Internal function: middleware giving every request a server span named after its method and route (GET /shopping-carts/:id)
+ * The span continues the trace of the W3C traceparent header when the client sends one,
+ * database and AWS calls made with the request context become its children. */
+func traceRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(untracedRoutes, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+				attribute.String("request_id", logging.RequestID(ctx)),
+			))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if code := c.GetString(logErrorCode); code != "" {
+			span.SetAttributes(attribute.String("error.type", code))
+		}
+		// client errors are the caller's, only server errors fail the span
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"hw8-onlinestore/loadgen"
+)
+
+/* loadgen sends a mix of create_cart / add_items / get_cart requests to the /shopping-carts API
+ * and writes one JSON record per request (operation, response_time, success, status_code, timestamp),
+ * the format the analysis scripts in SQL-vs-NoSQL read. SIGINT/SIGTERM stop the run early, the records are still written. */
+func main() {
+	defaultURL := os.Getenv("ALB_URL")
+	if defaultURL == "" {
+		defaultURL = "http://localhost:8080"
+	}
+
+	cfg := loadgen.Config{}
+	var mix, out string
+	flag.StringVar(&cfg.BaseURL, "url", defaultURL, "base URL of the service (ALB_URL if set)")
+	flag.DurationVar(&cfg.Duration, "duration", time.Minute, "how long requests are sent (0: until -requests are sent)")
+	flag.IntVar(&cfg.Requests, "requests", 0, "number of requests to send (0: no limit)")
+	flag.IntVar(&cfg.Concurrency, "concurrency", 10, "closed loop: number of workers; open loop: most requests in flight")
+	flag.Float64Var(&cfg.Rate, "rate", 0, "open loop arrival rate in requests per second (0: closed loop)")
+	flag.StringVar(&mix, "mix", loadgen.DefaultMix, "relative weights of the operations")
+	flag.IntVar(&cfg.Products, "products", 100000, "add_items draws product IDs from 1..n (the seeded catalog)")
+	flag.IntVar(&cfg.Items, "items", 2, "products per add_items request")
+	flag.Uint64Var(&cfg.FirstCustomer, "first-customer", uint64(time.Now().UnixMicro()), "first customer ID used by create_cart (default: current time in microseconds, so runs do not reuse customers with an active cart)")
+	flag.DurationVar(&cfg.Timeout, "timeout", 10*time.Second, "timeout of one request")
+	flag.Uint64Var(&cfg.Seed, "seed", 1, "random seed of the operation mix and the request bodies")
+	flag.StringVar(&out, "out", "loadgen_results.json", "file the records are written to")
+	flag.Parse()
+
+	parsed, err := loadgen.ParseMix(mix)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.Mix = parsed
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mode := "closed loop"
+	if cfg.Rate > 0 {
+		mode = fmt.Sprintf("open loop at %.1f requests/s", cfg.Rate)
+	}
+	log.Printf("Sending %s to %s/shopping-carts (%s, concurrency %d)", cfg.Mix, cfg.BaseURL, mode, cfg.Concurrency)
+	result, err := loadgen.Run(ctx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := loadgen.WriteRecordsFile(out, result.Records); err != nil {
+		log.Fatal(err)
+	}
+
+	printSummary(result)
+	log.Printf("%d records written to %s", len(result.Records), out)
+}
+
+/* Internal function: requests, success rate and mean response time per operation */
+func printSummary(result loadgen.Result) {
+	type summary struct {
+		requests, successes int
+		totalMs             float64
+	}
+	byOp := make(map[string]*summary)
+	for _, r := range result.Records {
+		s, ok := byOp[r.Operation]
+		if !ok {
+			s = &summary{}
+			byOp[r.Operation] = s
+		}
+		s.requests++
+		s.totalMs += r.ResponseTime
+		if r.Success {
+			s.successes++
+		}
+	}
+	ops := make([]string, 0, len(byOp))
+	for op := range byOp {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	fmt.Printf("%-12s %9s %9s %10s\n", "operation", "requests", "success", "mean (ms)")
+	for _, op := range ops {
+		s := byOp[op]
+		fmt.Printf("%-12s %9d %8.1f%% %10.2f\n", op, s.requests, 100*float64(s.successes)/float64(s.requests), s.totalMs/float64(s.requests))
+	}
+	fmt.Printf("%d requests in %s (%.1f requests/s)", len(result.Records), result.Elapsed.Round(time.Millisecond), float64(len(result.Records))/result.Elapsed.Seconds())
+	if result.Dropped > 0 {
+		fmt.Printf(", %d arrivals dropped (concurrency limit reached)", result.Dropped)
+	}
+	fmt.Println()
+}
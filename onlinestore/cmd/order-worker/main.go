@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"hw8-onlinestore/logging"
+	"hw8-onlinestore/setup"
+	"hw8-onlinestore/tracing"
+	"hw8-onlinestore/worker"
+)
+
+/* order-worker consumes OrderPlaced events from the order-processing-queue:
+ * every order is marked paid and its reserved inventory is committed.
+ * It uses the same DATABASE_TYPE / DB_* / DYNAMODB_TABLE_NAME settings as the API service,
+ * plus ORDER_QUEUE_URL, WORKER_CONCURRENCY and OTEL_TRACES_EXPORTER. SIGINT/SIGTERM stop polling and let in-flight messages finish. */
+func main() {
+	// JSON logs on stdout (log.Printf included), LOG_LEVEL debug | info (default) | warn | error
+	logging.Setup(os.Getenv("LOG_LEVEL"))
+
+	cfg, err := setup.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.OrderQueueURL == "" {
+		log.Fatal("ORDER_QUEUE_URL environment variable not set")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// one span per message in the trace of the checkout that published it, OTEL_TRACES_EXPORTER otlp | stdout | none (default)
+	shutdownTracing, err := tracing.Setup(ctx, "order-worker", os.Getenv("OTEL_TRACES_EXPORTER"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		// flush the spans still buffered, ctx is already cancelled
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(flushCtx)
+	}()
+
+	backend, err := setup.OpenBackend(ctx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer backend.Close()
+
+	awsCfg, err := setup.AWSConfig(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	queue := worker.NewSQSQueue(sqs.NewFromConfig(awsCfg), cfg.OrderQueueURL)
+
+	slog.Info("starting order worker", "database", cfg.DatabaseType, "pollers", cfg.WorkerConcurrency, "queue_url", cfg.OrderQueueURL)
+	if err := worker.New(queue, backend.Carts, cfg.WorkerConcurrency).Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+	slog.Info("order worker stopped")
+}
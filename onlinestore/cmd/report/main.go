@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hw8-onlinestore/report"
+)
+
+/* report compares load test results (loadgen or the Python performance tests) of any number of backends:
+ * avg/p50/p95/p99 response times, success rates, per-operation breakdown, histograms, throughput over time
+ * and 95% confidence intervals, written as Markdown, CSV and HTML.
+ *
+ *	report [flags] mysql=mysql_test_results.json dynamodb=dynamodb_test_results.json [mysql=more.json ...]
+ *	report [flags] combined_results.json
+ *
+ * Files tagged with the same backend are merged; an untagged file is an object of record arrays keyed by backend. */
+func main() {
+	var out, formats, title string
+	var interval time.Duration
+	flag.StringVar(&out, "out", "report", "directory the report files are written to")
+	flag.StringVar(&formats, "format", "md,csv,html", "comma-separated output formats (md, csv, html)")
+	flag.StringVar(&title, "title", "SQL vs NoSQL comparison", "title of the report")
+	flag.DurationVar(&interval, "interval", time.Second, "width of the throughput over time intervals")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: report [flags] backend=results.json ... | combined_results.json")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// every output file with the function writing it, by format
+	type output struct {
+		name  string
+		write func(r report.Report, w io.Writer) error
+	}
+	writers := map[string][]output{
+		"md":   {{"report.md", report.Report.WriteMarkdown}},
+		"html": {{"report.html", report.Report.WriteHTML}},
+		"csv": {
+			{"summary.csv", report.Report.WriteSummaryCSV},
+			{"histogram.csv", report.Report.WriteHistogramCSV},
+			{"throughput.csv", report.Report.WriteThroughputCSV},
+		},
+	}
+	selected := make([]string, 0)
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.TrimSpace(format)
+		if _, ok := writers[format]; !ok {
+			log.Fatalf("unknown format '%s' (formats: md, csv, html)", format)
+		}
+		selected = append(selected, format)
+	}
+
+	datasets := make([]report.Dataset, 0)
+	for _, arg := range flag.Args() {
+		backend, path, tagged := strings.Cut(arg, "=")
+		if !tagged {
+			backend, path = "", arg
+		}
+		loaded, err := report.LoadFile(path, backend)
+		if err != nil {
+			log.Fatal(err)
+		}
+		datasets = append(datasets, loaded...)
+	}
+
+	r, err := report.Build(title, report.Merge(datasets), interval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	for _, format := range selected {
+		for _, o := range writers[format] {
+			path := filepath.Join(out, o.name)
+			if err := writeFile(path, r, o.write); err != nil {
+				log.Fatalf("failed to write %s: %v", path, err)
+			}
+			log.Println("Wrote", path)
+		}
+	}
+}
+
+/* Internal function: create path and write the report to it */
+func writeFile(path string, r report.Report, write func(r report.Report, w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(r, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
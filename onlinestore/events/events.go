@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hw8-onlinestore/store"
+)
+
+// event types and schema versions
+const (
+	OrderPlacedType    = "OrderPlaced"
+	OrderPlacedVersion = 1
+)
+
+// define OrderPlaced event struct (JSON body of the SNS/SQS message)
+type OrderItem struct {
+	ProductID   int32  `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    uint   `json:"quantity"`
+}
+type OrderPlaced struct {
+	EventID    string      `json:"event_id"`
+	EventType  string      `json:"event_type"`
+	Version    int         `json:"version"`
+	CartID     string      `json:"cart_id"`
+	CustomerID uint64      `json:"customer_id"`
+	Items      []OrderItem `json:"items"`
+	Timestamp  time.Time   `json:"timestamp"`
+}
+
+/* NewOrderPlaced builds the OrderPlaced event of a cart that was just checked out */
+func NewOrderPlaced(cart store.Cart) OrderPlaced {
+	items := make([]OrderItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		items = append(items, OrderItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+		})
+	}
+	return OrderPlaced{
+		EventID:    uuid.New().String(),
+		EventType:  OrderPlacedType,
+		Version:    OrderPlacedVersion,
+		CartID:     cart.CartID,
+		CustomerID: cart.CustomerID,
+		Items:      items,
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+/* Publisher sends order events to the order-processing pipeline */
+type Publisher interface {
+	Publish(ctx context.Context, event OrderPlaced) error
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// number of recent events kept by a MemoryPublisher (older events are only in the logs)
+const memoryPublisherLimit = 1000
+
+/* MemoryPublisher is an in-process fake Publisher for local runs: it logs every published event
+ * and keeps the most recent memoryPublisherLimit of them */
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []OrderPlaced
+}
+
+/* NewMemoryPublisher returns an empty MemoryPublisher */
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event OrderPlaced) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.events) == memoryPublisherLimit {
+		// drop the oldest event
+		copy(p.events, p.events[1:])
+		p.events = p.events[:len(p.events)-1]
+	}
+	p.events = append(p.events, event)
+	slog.InfoContext(ctx, "order event published", "event_type", event.EventType, "event_version", event.Version, "cart_id", event.CartID, "items", len(event.Items))
+	return nil
+}
+
+/* Events returns a copy of the most recent published events, oldest first */
+func (p *MemoryPublisher) Events() []OrderPlaced {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]OrderPlaced(nil), p.events...)
+}
+
+// compile-time check
+var _ Publisher = (*MemoryPublisher)(nil)
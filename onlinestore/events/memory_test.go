@@ -0,0 +1,24 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestMemoryPublisherKeepsRecentEvents(t *testing.T) {
+	p := NewMemoryPublisher()
+	for i := range memoryPublisherLimit + 5 {
+		if err := p.Publish(context.Background(), OrderPlaced{CartID: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("failed to publish event %d: %v", i, err)
+		}
+	}
+
+	events := p.Events()
+	if len(events) != memoryPublisherLimit {
+		t.Fatalf("expected %d events, got %d", memoryPublisherLimit, len(events))
+	}
+	if first, last := events[0].CartID, events[len(events)-1].CartID; first != "5" || last != fmt.Sprint(memoryPublisherLimit+4) {
+		t.Fatalf("expected events 5 to %d, got %s to %s", memoryPublisherLimit+4, first, last)
+	}
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+
+	"hw8-onlinestore/tracing"
+)
+
+/* SNSPublisher publishes events as JSON messages to an SNS topic (order-processing-events)
+ * event_type and version are also sent as message attributes so subscribers can filter on them,
+ * next to the W3C trace context of the publishing request. */
+type SNSPublisher struct {
+	client   *sns.Client
+	topicARN string
+}
+
+/* NewSNSPublisher returns a Publisher for the topic topicARN */
+func NewSNSPublisher(client *sns.Client, topicARN string) *SNSPublisher {
+	return &SNSPublisher{client: client, topicARN: topicARN}
+}
+
+func (p *SNSPublisher) Publish(ctx context.Context, event OrderPlaced) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event.EventType, err)
+	}
+
+	attributes := map[string]types.MessageAttributeValue{
+		"event_type": {DataType: aws.String("String"), StringValue: aws.String(event.EventType)},
+		"version":    {DataType: aws.String("Number"), StringValue: aws.String(strconv.Itoa(event.Version))},
+	}
+	// trace context of the checkout (traceparent), the order worker continues its trace
+	carrier := make(map[string]string)
+	tracing.Inject(ctx, carrier)
+	for key, value := range carrier {
+		attributes[key] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+	}
+
+	_, err = p.client.Publish(ctx, &sns.PublishInput{
+		TopicArn:          aws.String(p.topicARN),
+		Message:           aws.String(string(body)),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish %s event for cart %s: %w", event.EventType, event.CartID, err)
+	}
+	return nil
+}
+
+// compile-time check
+var _ Publisher = (*SNSPublisher)(nil)
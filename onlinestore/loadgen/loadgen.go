@@ -0,0 +1,257 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// define Config struct (one load test run)
+type Config struct {
+	BaseURL       string        // e.g. http://localhost:8080, /shopping-carts is appended
+	Duration      time.Duration // stop sending requests after Duration
+	Requests      int           // stop after Requests requests (0: no limit)
+	Concurrency   int           // closed loop: number of workers; open loop: most requests in flight
+	Rate          float64       // open loop arrivals per second (Poisson process), 0 for a closed loop
+	Mix           Mix
+	Products      int    // add_items draws product IDs from 1..Products
+	Items         int    // products per add_items request
+	FirstCustomer uint64 // create_cart uses customer IDs FirstCustomer, FirstCustomer+1, ...
+	Timeout       time.Duration
+	Seed          uint64
+}
+
+// define Result struct
+// Dropped counts the open loop arrivals that were not sent because Concurrency requests were in flight
+type Result struct {
+	Records []Record
+	Dropped int
+	Elapsed time.Duration
+}
+
+// define request struct (an operation with its target, drawn before it is sent)
+type request struct {
+	op     string
+	cartID string
+	body   []byte
+}
+
+// define runner struct (state shared by the workers of a run)
+type runner struct {
+	cfg       Config
+	client    *http.Client
+	customers atomic.Uint64
+
+	mu      sync.Mutex
+	carts   []string // carts created during the run, targets of add_items and get_cart
+	records []Record
+}
+
+/* Run sends requests to the /shopping-carts API until the duration or the number of requests is reached,
+ * or ctx is cancelled, then waits for the requests in flight.
+ * Closed loop (Rate 0): Concurrency workers send their next request as soon as the previous one returns.
+ * Open loop (Rate > 0): requests arrive at Rate per second whatever the response times, so a slow server
+ * builds up requests in flight instead of slowing the load down. */
+func Run(ctx context.Context, cfg Config) (Result, error) {
+	if cfg.Concurrency < 1 || cfg.Products < 1 || cfg.Items < 1 {
+		return Result{}, errors.New("concurrency, products and items must be >= 1")
+	}
+	if u, err := url.Parse(cfg.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Result{}, fmt.Errorf("base URL must be an http(s) URL such as http://localhost:8080 (input: %s)", cfg.BaseURL)
+	}
+	if cfg.Duration <= 0 && cfg.Requests <= 0 {
+		return Result{}, errors.New("a duration or a number of requests is needed")
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = time.Duration(1<<63 - 1)
+	}
+
+	r := &runner{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        cfg.Concurrency,
+				MaxIdleConnsPerHost: cfg.Concurrency,
+			},
+		},
+		records: make([]Record, 0, 1024),
+	}
+	r.customers.Store(cfg.FirstCustomer)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	start := time.Now()
+	var dropped int
+	if cfg.Rate > 0 {
+		dropped = r.openLoop(ctx)
+	} else {
+		r.closedLoop(ctx)
+	}
+	return Result{Records: r.records, Dropped: dropped, Elapsed: time.Since(start)}, nil
+}
+
+/* Internal function: Concurrency workers, each with its own random generator, share the request budget */
+func (r *runner) closedLoop(ctx context.Context) {
+	var sent atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < r.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func(worker uint64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(r.cfg.Seed, worker))
+			for ctx.Err() == nil {
+				if r.cfg.Requests > 0 && sent.Add(1) > int64(r.cfg.Requests) {
+					return
+				}
+				r.send(ctx, r.next(rng))
+			}
+		}(uint64(i))
+	}
+	wg.Wait()
+}
+
+/* Internal function: draw exponential inter-arrival times and send every arrival in its own goroutine,
+ * an arrival finding Concurrency requests in flight is dropped (and counted) */
+func (r *runner) openLoop(ctx context.Context) int {
+	rng := rand.New(rand.NewPCG(r.cfg.Seed, 0))
+	inFlight := make(chan struct{}, r.cfg.Concurrency)
+	var wg sync.WaitGroup
+	dropped := 0
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	next := time.Now()
+	for sent := 0; r.cfg.Requests == 0 || sent < r.cfg.Requests; sent++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return dropped
+		case <-timer.C:
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+			wg.Add(1)
+			go func(req request) {
+				defer wg.Done()
+				r.send(ctx, req)
+				<-inFlight
+			}(r.next(rng))
+		default:
+			dropped++
+		}
+
+		next = next.Add(time.Duration(rng.ExpFloat64() / r.cfg.Rate * float64(time.Second)))
+		timer.Reset(time.Until(next))
+	}
+	wg.Wait()
+	return dropped
+}
+
+/* Internal function: draw the next request; add_items and get_cart need a cart,
+ * they fall back to create_cart until one was created */
+func (r *runner) next(rng *rand.Rand) request {
+	op := r.cfg.Mix.pick(rng)
+	if op == OpCreateCart {
+		return request{op: op}
+	}
+
+	r.mu.Lock()
+	if len(r.carts) == 0 {
+		r.mu.Unlock()
+		return request{op: OpCreateCart}
+	}
+	cartID := r.carts[rng.IntN(len(r.carts))]
+	r.mu.Unlock()
+
+	if op == OpGetCart {
+		return request{op: op, cartID: cartID}
+	}
+	// distinct random products, 1 to 3 units each
+	type item struct {
+		ProductID int32 `json:"product_id"`
+		Quantity  uint  `json:"quantity"`
+	}
+	count := min(r.cfg.Items, r.cfg.Products)
+	picked := make(map[int32]bool, count)
+	items := make([]item, 0, count)
+	for len(items) < count {
+		productID := int32(1 + rng.IntN(r.cfg.Products))
+		if !picked[productID] {
+			picked[productID] = true
+			items = append(items, item{ProductID: productID, Quantity: uint(1 + rng.IntN(3))})
+		}
+	}
+	body, _ := json.Marshal(map[string]any{"items": items})
+	return request{op: op, cartID: cartID, body: body}
+}
+
+/* Internal function: send one request and record its response time and status code */
+func (r *runner) send(ctx context.Context, req request) {
+	target := strings.TrimSuffix(r.cfg.BaseURL, "/") + "/shopping-carts"
+	method := http.MethodPost
+	body := req.body
+	switch req.op {
+	case OpCreateCart:
+		// every cart gets a new customer: a customer can only have one active cart
+		body = fmt.Appendf(nil, `{"customer_id": %d}`, r.customers.Add(1)-1)
+	case OpAddItems:
+		target += "/" + req.cartID + "/items"
+	case OpGetCart:
+		target += "/" + req.cartID
+		method = http.MethodGet
+	}
+
+	// requests in flight when the run ends are allowed to finish (up to the client timeout)
+	start := time.Now()
+	httpReq, err := http.NewRequestWithContext(context.WithoutCancel(ctx), method, target, bytes.NewReader(body))
+	var resp *http.Response
+	if err == nil {
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		resp, err = r.client.Do(httpReq)
+	}
+	var cartID string
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+		if req.op == OpCreateCart && status == http.StatusCreated {
+			var created struct {
+				CartID json.RawMessage `json:"cart_id"`
+			}
+			if json.NewDecoder(resp.Body).Decode(&created) == nil {
+				// MySQL returns a number as a string, DynamoDB a UUID, accept both JSON types
+				cartID = strings.Trim(string(created.CartID), `"`)
+			}
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	elapsed := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cartID != "" {
+		r.carts = append(r.carts, cartID)
+	}
+	r.records = append(r.records, Record{
+		Operation:    req.op,
+		ResponseTime: float64(elapsed.Microseconds()) / 1000,
+		Success:      status >= 200 && status < 300,
+		StatusCode:   status,
+		Timestamp:    start.UTC(),
+	})
+}
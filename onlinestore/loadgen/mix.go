@@ -0,0 +1,82 @@
+package loadgen
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// operations of the /shopping-carts API (the operation names of the Python performance tests)
+const (
+	OpCreateCart = "create_cart" // POST /shopping-carts
+	OpAddItems   = "add_items"   // POST /shopping-carts/:id/items
+	OpGetCart    = "get_cart"    // GET /shopping-carts/:id
+)
+
+var operations = []string{OpCreateCart, OpAddItems, OpGetCart}
+
+// DefaultMix is the mix of the Python performance tests (as many creates as adds and gets)
+const DefaultMix = "create_cart=1,add_items=1,get_cart=1"
+
+// define Mix struct (relative weight of every operation)
+type Mix struct {
+	ops     []string
+	weights []int
+	total   int
+}
+
+/* ParseMix parses "operation=weight,..." (operations left out get weight 0) */
+func ParseMix(value string) (Mix, error) {
+	var mix Mix
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		op, weightText, ok := strings.Cut(part, "=")
+		op = strings.TrimSpace(op)
+		if !ok {
+			return Mix{}, fmt.Errorf("invalid mix entry '%s' (expected operation=weight)", part)
+		}
+		if !slices.Contains(operations, op) {
+			return Mix{}, fmt.Errorf("unknown operation '%s' (operations: %s)", op, strings.Join(operations, ", "))
+		}
+		if slices.Contains(mix.ops, op) {
+			return Mix{}, fmt.Errorf("duplicate operation '%s' in mix", op)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightText))
+		if err != nil || weight < 0 {
+			return Mix{}, fmt.Errorf("weight of '%s' must be an integer >= 0 (input: %s)", op, weightText)
+		}
+		mix.ops = append(mix.ops, op)
+		mix.weights = append(mix.weights, weight)
+		mix.total += weight
+	}
+	if mix.total == 0 {
+		return Mix{}, fmt.Errorf("mix needs at least one operation with a weight > 0 (input: %s)", value)
+	}
+	return mix, nil
+}
+
+/* Internal function: draw an operation according to the weights */
+func (m Mix) pick(rng *rand.Rand) string {
+	n := rng.IntN(m.total)
+	for i, weight := range m.weights {
+		if n < weight {
+			return m.ops[i]
+		}
+		n -= weight
+	}
+	return m.ops[len(m.ops)-1]
+}
+
+/* String formats the mix like ParseMix expects it */
+func (m Mix) String() string {
+	parts := make([]string, len(m.ops))
+	for i, op := range m.ops {
+		parts[i] = fmt.Sprintf("%s=%d", op, m.weights[i])
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,57 @@
+package loadgen
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+)
+
+// define Record struct (one request, the format of the *_test_results.json files read by the analysis scripts)
+// StatusCode is 0 when no response was received (timeout, connection error)
+type Record struct {
+	Operation    string    `json:"operation"`
+	ResponseTime float64   `json:"response_time"` // milliseconds
+	Success      bool      `json:"success"`
+	StatusCode   int       `json:"status_code"`
+	Timestamp    time.Time `json:"timestamp"` // when the request was sent
+}
+
+/* WriteRecords writes records as an indented JSON array to w */
+func WriteRecords(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+/* WriteRecordsFile creates (or truncates) path and writes records to it */
+func WriteRecordsFile(path string, records []Record) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteRecords(f, records); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+/* ReadRecords decodes a JSON array of records */
+func ReadRecords(r io.Reader) ([]Record, error) {
+	records := make([]Record, 0)
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+/* ReadRecordsFile reads the records written by WriteRecordsFile (or by the Python performance tests) */
+func ReadRecordsFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRecords(f)
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+/* Setup makes slog's default logger write JSON lines to stdout at level (debug, info, warn or error, default info)
+ * Output of the standard log package (log.Printf) goes through the same handler as "msg" at level INFO,
+ * and records logged with a request context (slog.InfoContext) carry its request_id and trace_id. */
+func Setup(level string) {
+	var lvl slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "warn":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		lvl = slog.LevelInfo
+	}
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: lvl})
+	slog.SetDefault(slog.New(requestHandler{handler}))
+}
+
+// define request struct (per-request state carried by the request context)
+type request struct {
+	id      string
+	dbNanos atomic.Int64
+	dbCalls atomic.Int64
+}
+
+type requestKey struct{}
+
+/* WithRequestID returns a context carrying requestID and a counter of the database time spent for the request */
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{id: requestID})
+}
+
+/* RequestID returns the request ID carried by ctx, "" outside of a request */
+func RequestID(ctx context.Context) string {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		return r.id
+	}
+	return ""
+}
+
+/* AddDBTime adds one database call of duration to the request carried by ctx (no-op outside of a request) */
+func AddDBTime(ctx context.Context, duration time.Duration) {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		r.dbNanos.Add(int64(duration))
+		r.dbCalls.Add(1)
+	}
+}
+
+/* DBTime returns the time spent in database calls by the request carried by ctx and the number of calls */
+func DBTime(ctx context.Context) (time.Duration, int) {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		return time.Duration(r.dbNanos.Load()), int(r.dbCalls.Load())
+	}
+	return 0, 0
+}
+
+// define requestHandler struct (slog.Handler adding the request_id and the trace_id / span_id of the record's context)
+type requestHandler struct {
+	slog.Handler
+}
+
+func (h requestHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h requestHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestHandler) WithGroup(name string) slog.Handler {
+	return requestHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+
+	"hw8-onlinestore/api"
+	"hw8-onlinestore/events"
+	"hw8-onlinestore/logging"
+	"hw8-onlinestore/setup"
+	"hw8-onlinestore/tracing"
+)
+
+// constants
+const DataSize = 100000
+
+func main() {
+	// JSON logs on stdout (log.Printf included), LOG_LEVEL debug | info (default) | warn | error
+	logging.Setup(os.Getenv("LOG_LEVEL"))
+
+	cfg, err := setup.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// subcommands: "migrate" manages the MySQL schema, "seed" generates data, then exit
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			err = runMigrate(ctx, cfg, os.Args[2:])
+		case "seed":
+			err = runSeed(ctx, cfg, os.Args[2:])
+		default:
+			log.Fatalf("unknown command '%s' (expected migrate or seed, or no command to run the server)", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Spans of the requests and their database and AWS calls, OTEL_TRACES_EXPORTER otlp | stdout | none (default)
+	shutdownTracing, err := tracing.Setup(ctx, "onlinestore", os.Getenv("OTEL_TRACES_EXPORTER"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Initialize the backend selected by DATABASE_TYPE
+	backend, err := setup.OpenBackend(ctx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer backend.Close()
+
+	// The in-memory catalog starts empty with every process: generate the default 100,000 products
+	// (MySQL and DynamoDB are seeded once with the seed subcommand)
+	if cfg.DatabaseType == setup.DatabaseMemory {
+		if err := seed(ctx, backend, defaultSeedOptions()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	opts := api.Options{Carts: backend.Carts, Products: backend.Products, IdempotencyTTL: cfg.IdempotencyTTL}
+
+	// Order events go to SNS when a topic is configured, otherwise to an in-process fake
+	if cfg.OrderEventsTopicARN != "" {
+		awsCfg, err := setup.AWSConfig(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Publisher = events.NewSNSPublisher(sns.NewFromConfig(awsCfg), cfg.OrderEventsTopicARN)
+		slog.Info("publishing order events", "topic_arn", cfg.OrderEventsTopicARN)
+	} else {
+		opts.Publisher = events.NewMemoryPublisher()
+	}
+
+	router := api.NewRouter(opts)
+
+	slog.Info("starting server", "database", cfg.DatabaseType, "addr", cfg.Addr)
+	if err := router.Run(cfg.Addr); err != nil {
+		log.Fatal(err)
+	}
+}
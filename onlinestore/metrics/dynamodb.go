@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+/* InstrumentDynamoDB is a dynamodb.Options function (dynamodb.NewFromConfig(cfg, metrics.InstrumentDynamoDB))
+ * timing every call in db_operation_duration_seconds and counting the capacity it consumed.
+ * Calls that do not ask for their consumed capacity are changed to ask for the TOTAL. */
+func InstrumentDynamoDB(o *dynamodb.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("OnlinestoreMetrics", observeDynamoDB), middleware.Before)
+	})
+}
+
+/* Internal function: initialize step middleware, runs once per call around the retries */
+func observeDynamoDB(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	operation := middleware.GetOperationName(ctx)
+
+	// every operation that reports consumed capacity has a ReturnConsumedCapacity input field
+	if field := structField(in.Parameters, "ReturnConsumedCapacity"); field.IsValid() && field.String() == "" {
+		field.SetString(string(types.ReturnConsumedCapacityTotal))
+	}
+
+	start := time.Now()
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	ObserveDB(ctx, "dynamodb", operation, start, err)
+	if err != nil {
+		return out, metadata, err
+	}
+
+	// single-table operations return one ConsumedCapacity, batch and transaction operations one per table
+	switch capacity := structField(out.Result, "ConsumedCapacity"); {
+	case !capacity.IsValid():
+	case capacity.Type() == reflect.TypeFor[*types.ConsumedCapacity]():
+		addCapacity(operation, capacity.Interface().(*types.ConsumedCapacity))
+	case capacity.Type() == reflect.TypeFor[[]types.ConsumedCapacity]():
+		for _, c := range capacity.Interface().([]types.ConsumedCapacity) {
+			addCapacity(operation, &c)
+		}
+	}
+	return out, metadata, err
+}
+
+/* Internal function: settable field name of the struct v points to (invalid Value if there is none) */
+func structField(v any, name string) reflect.Value {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return value.Elem().FieldByName(name)
+}
+
+/* Internal function: add the capacity units of one table */
+func addCapacity(operation string, capacity *types.ConsumedCapacity) {
+	if capacity == nil || capacity.CapacityUnits == nil {
+		return
+	}
+	table := ""
+	if capacity.TableName != nil {
+		table = *capacity.TableName
+	}
+	dynamoDBCapacity.WithLabelValues(operation, table).Add(*capacity.CapacityUnits)
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"hw8-onlinestore/logging"
+)
+
+// prefix of the metric names
+const namespace = "onlinestore"
+
+// latency buckets in seconds, from 0.5 ms (in-memory / same-AZ calls) to 10 s (client timeouts)
+var latencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+/* Registry holds the metrics served by Handler (Go runtime and process metrics included) */
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent handling HTTP requests, by method, route and status code.",
+		Buckets:   latencyBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_operation_duration_seconds",
+		Help:      "Time spent in database calls (SDK retries included), by backend, operation and result (ok or error).",
+		Buckets:   latencyBuckets,
+	}, []string{"backend", "operation", "result"})
+
+	dynamoDBCapacity = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dynamodb_consumed_capacity_units_total",
+		Help:      "DynamoDB capacity units consumed, by operation and table.",
+	}, []string{"operation", "table"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, dbDuration, dynamoDBCapacity,
+	)
+}
+
+/* Handler serves the metrics of Registry in the Prometheus text format */
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+/* ObserveHTTP records a handled request; route is the route pattern (e.g. /shopping-carts/:id), not the request path */
+func ObserveHTTP(method string, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+/* ObserveDB records a database call started at start (backend: mysql or dynamodb, operation: e.g. QueryRow or PutItem)
+ * The call also counts in the database time logged for the request carried by ctx. */
+func ObserveDB(ctx context.Context, backend string, operation string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	dbDuration.WithLabelValues(backend, operation, result).Observe(elapsed.Seconds())
+	logging.AddDBTime(ctx, elapsed)
+}
+
+/* RegisterDBStats exports the connection pool statistics of db (db.Stats()) as go_sql_* gauges and counters labeled db_name */
+func RegisterDBStats(db *sql.DB, name string) {
+	err := Registry.Register(collectors.NewDBStatsCollector(db, name))
+	var registered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &registered) {
+		slog.Error("failed to register connection pool metrics", "db_name", name, "error", err)
+	}
+}
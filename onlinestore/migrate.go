@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"hw8-onlinestore/setup"
+	"hw8-onlinestore/store/mysqlstore"
+)
+
+/* runMigrate implements "online-store migrate [up|down|status] [-steps n]" for the MySQL schema
+ * up applies the pending migrations (all of them by default), down reverts the last one (or -steps of them) */
+func runMigrate(ctx context.Context, cfg setup.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := flags.Int("steps", 0, "number of migrations to apply or revert (up: 0 = all pending, down: default 1)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: online-store migrate [up|down|status] [-steps n]")
+		flags.PrintDefaults()
+	}
+
+	command := "up"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		command, args = args[0], args[1:]
+	}
+	flags.Parse(args)
+	if command != "up" && command != "down" && command != "status" {
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command '%s'", command)
+	}
+
+	if cfg.DatabaseType != setup.DatabaseMySQL {
+		return fmt.Errorf("migrations only apply to DATABASE_TYPE=%s (current: %s)", setup.DatabaseMySQL, cfg.DatabaseType)
+	}
+	s, err := mysqlstore.Connect(ctx, cfg.MySQL)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	switch command {
+	case "up":
+		return s.MigrateUp(ctx, *steps)
+	case "down":
+		return s.MigrateDown(ctx, max(*steps, 1))
+	case "status":
+		statuses, err := s.Migrations(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range statuses {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = "applied " + m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%s\t%s\n", m.Version, m.Name, applied)
+		}
+	}
+	return nil
+}
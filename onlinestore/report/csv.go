@@ -0,0 +1,75 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+)
+
+/* WriteSummaryCSV writes one row of statistics per backend and operation ("all" for every operation of the backend) */
+func (r Report) WriteSummaryCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"backend", "operation", "count", "successes", "success_rate", "success_rate_low", "success_rate_high",
+		"mean_ms", "mean_low_ms", "mean_high_ms", "std_dev_ms", "min_ms", "max_ms",
+		"p50_ms", "p50_low_ms", "p50_high_ms", "p95_ms", "p95_low_ms", "p95_high_ms", "p99_ms", "p99_low_ms", "p99_high_ms",
+		"throughput_per_s",
+	})
+	for _, b := range r.Backends {
+		for _, s := range append([]Stats{b.All}, b.Operations...) {
+			throughput := ""
+			if s.Operation == b.All.Operation {
+				throughput = formatFloat(b.Throughput)
+			}
+			out.Write([]string{
+				b.Backend, s.Operation, strconv.Itoa(s.Count), strconv.Itoa(s.Successes),
+				formatFloat(s.SuccessRate), formatFloat(s.SuccessRateCI.Low), formatFloat(s.SuccessRateCI.High),
+				formatFloat(s.Mean), formatFloat(s.MeanCI.Low), formatFloat(s.MeanCI.High),
+				formatFloat(s.StdDev), formatFloat(s.Min), formatFloat(s.Max),
+				formatFloat(s.P50), formatFloat(s.P50CI.Low), formatFloat(s.P50CI.High),
+				formatFloat(s.P95), formatFloat(s.P95CI.Low), formatFloat(s.P95CI.High),
+				formatFloat(s.P99), formatFloat(s.P99CI.Low), formatFloat(s.P99CI.High),
+				throughput,
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+/* WriteHistogramCSV writes the request count of every histogram bucket per backend and operation */
+func (r Report) WriteHistogramCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"backend", "operation", "lower_ms", "upper_ms", "count"})
+	for _, b := range r.Backends {
+		for _, s := range append([]Stats{b.All}, b.Operations...) {
+			for i, count := range s.Histogram {
+				out.Write([]string{b.Backend, s.Operation, formatFloat(r.BucketEdges[i]), formatFloat(r.BucketEdges[i+1]), strconv.Itoa(count)})
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+/* WriteThroughputCSV writes the requests of every throughput interval per backend */
+func (r Report) WriteThroughputCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"backend", "offset_s", "requests", "successes", "requests_per_s"})
+	for _, b := range r.Backends {
+		for _, p := range b.Timeline {
+			out.Write([]string{
+				b.Backend, formatFloat(p.Offset.Seconds()), strconv.Itoa(p.Requests), strconv.Itoa(p.Successes),
+				formatFloat(float64(p.Requests) / r.Interval.Seconds()),
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+/* Internal function: shortest representation of a float, rounded to 4 decimals */
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e4)/1e4, 'f', -1, 64)
+}
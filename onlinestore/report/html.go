@@ -0,0 +1,172 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// colors of the backends in the charts
+var chartColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"}
+
+// size of the SVG charts (plot area inside the margins)
+const (
+	chartWidth  = 860
+	chartHeight = 260
+	chartMargin = 50
+)
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 1000px; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #f3f3f3; }
+.note { color: #555; }
+svg text { font-size: 11px; fill: #333; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Generated {{.Generated}} from:</p>
+<ul>
+{{- range .Sources}}
+<li><strong>{{.Backend}}</strong>: {{.Requests}} requests from {{.Start}} over {{.Duration}}</li>
+{{- end}}
+</ul>
+{{range .Sections}}
+<h2>{{.Title}}</h2>
+{{if .Note}}<p class="note">{{.Note}}</p>{{end}}
+{{.Chart}}
+<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+/* WriteHTML renders the report as a standalone HTML page, the histogram and the throughput over time are also drawn as SVG charts */
+func (r Report) WriteHTML(w io.Writer) error {
+	type source struct {
+		Backend  string
+		Requests int
+		Start    string
+		Duration time.Duration
+	}
+	type section struct {
+		table
+		Chart template.HTML
+	}
+	page := struct {
+		Title     string
+		Generated string
+		Sources   []source
+		Sections  []section
+	}{Title: r.Title, Generated: r.Generated.Format(time.RFC3339)}
+
+	for _, b := range r.Backends {
+		page.Sources = append(page.Sources, source{b.Backend, b.All.Count, b.Start.Format(time.RFC3339), b.Duration.Round(time.Millisecond)})
+	}
+	for _, t := range r.tables() {
+		s := section{table: t}
+		if t.chart != nil {
+			s.Chart = t.chart()
+		}
+		page.Sections = append(page.Sections, s)
+	}
+	return htmlReport.Execute(w, page)
+}
+
+/* Internal function: grouped bars of the share of requests (%) of every backend in every histogram bucket */
+func (r Report) histogramChart() template.HTML {
+	buckets := len(r.BucketEdges) - 1
+	highest := 0.0
+	shares := make([][]float64, len(r.Backends))
+	for i, b := range r.Backends {
+		shares[i] = make([]float64, buckets)
+		for j, count := range b.All.Histogram {
+			shares[i][j] = 100 * float64(count) / float64(b.All.Count)
+			highest = max(highest, shares[i][j])
+		}
+	}
+
+	var svg strings.Builder
+	r.chartFrame(&svg, "share of requests (%)", highest)
+	slot := float64(chartWidth) / float64(buckets)
+	bar := slot * 0.8 / float64(len(r.Backends))
+	for j := range buckets {
+		x := chartMargin + float64(j)*slot
+		for i := range r.Backends {
+			height := shares[i][j] / highest * chartHeight
+			fmt.Fprintf(&svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s ms: %.1f%%</title></rect>`,
+				x+slot*0.1+float64(i)*bar, chartMargin+chartHeight-height, bar, height, chartColors[i%len(chartColors)],
+				html.EscapeString(r.Backends[i].Backend), r.bucketLabel(j), shares[i][j])
+		}
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%d" text-anchor="middle">%g</text>`, x, chartMargin+chartHeight+15, r.BucketEdges[j])
+	}
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle">%g</text>`, chartMargin+chartWidth, chartMargin+chartHeight+15, r.BucketEdges[buckets])
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle">response time (ms)</text>`, chartMargin+chartWidth/2, chartMargin+chartHeight+35)
+	return r.closeChart(&svg)
+}
+
+/* Internal function: one line of requests per second per backend, over the time since its first record */
+func (r Report) throughputChart() template.HTML {
+	seconds := r.Interval.Seconds()
+	points, highest := 0, 0.0
+	for _, b := range r.Backends {
+		points = max(points, len(b.Timeline))
+		for _, p := range b.Timeline {
+			highest = max(highest, float64(p.Requests)/seconds)
+		}
+	}
+
+	var svg strings.Builder
+	r.chartFrame(&svg, "requests/s", highest)
+	step := float64(chartWidth) / float64(max(points-1, 1))
+	for i, b := range r.Backends {
+		coordinates := make([]string, len(b.Timeline))
+		for j, p := range b.Timeline {
+			coordinates[j] = fmt.Sprintf("%.1f,%.1f", chartMargin+float64(j)*step, chartMargin+chartHeight-float64(p.Requests)/seconds/highest*chartHeight)
+		}
+		fmt.Fprintf(&svg, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(coordinates, " "), chartColors[i%len(chartColors)])
+	}
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="start">0 s</text>`, chartMargin, chartMargin+chartHeight+15)
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="end">%g s</text>`, chartMargin+chartWidth, chartMargin+chartHeight+15, float64(points-1)*seconds)
+	return r.closeChart(&svg)
+}
+
+/* Internal function: open an SVG chart with its axes, the y axis label and maximum, and the legend of the backends */
+func (r Report) chartFrame(svg *strings.Builder, yLabel string, highest float64) {
+	if highest == 0 {
+		highest = 1 // empty chart
+	}
+	fmt.Fprintf(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, chartWidth+2*chartMargin, chartHeight+2*chartMargin)
+	fmt.Fprintf(svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`, chartMargin, chartMargin, chartMargin, chartMargin+chartHeight)
+	fmt.Fprintf(svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`, chartMargin, chartMargin+chartHeight, chartMargin+chartWidth, chartMargin+chartHeight)
+	fmt.Fprintf(svg, `<text x="%d" y="%d" text-anchor="end">%.1f</text>`, chartMargin-5, chartMargin+4, highest)
+	fmt.Fprintf(svg, `<text x="%d" y="%d" text-anchor="end">0</text>`, chartMargin-5, chartMargin+chartHeight+4)
+	fmt.Fprintf(svg, `<text x="%d" y="%d">%s</text>`, chartMargin, chartMargin-20, yLabel)
+	for i, b := range r.Backends {
+		x := chartMargin + chartWidth - 150*(len(r.Backends)-i)
+		fmt.Fprintf(svg, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>`, x, chartMargin-30, chartColors[i%len(chartColors)])
+		fmt.Fprintf(svg, `<text x="%d" y="%d">%s</text>`, x+16, chartMargin-20, html.EscapeString(b.Backend))
+	}
+}
+
+/* Internal function: close an SVG chart opened by chartFrame */
+func (r Report) closeChart(svg *strings.Builder) template.HTML {
+	svg.WriteString("</svg>")
+	return template.HTML(svg.String())
+}
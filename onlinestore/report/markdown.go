@@ -0,0 +1,47 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+/* WriteMarkdown renders the report as GitHub-flavored Markdown tables */
+func (r Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	fmt.Fprintf(&b, "Generated %s from:\n\n", r.Generated.Format(time.RFC3339))
+	for _, backend := range r.Backends {
+		fmt.Fprintf(&b, "- **%s**: %d requests from %s over %s\n", backend.Backend, backend.All.Count,
+			backend.Start.Format(time.RFC3339), backend.Duration.Round(time.Millisecond))
+	}
+
+	for _, t := range r.tables() {
+		fmt.Fprintf(&b, "\n## %s\n\n", t.Title)
+		if t.Note != "" {
+			fmt.Fprintf(&b, "%s\n\n", t.Note)
+		}
+		writeMarkdownRow(&b, t.Header)
+		separators := make([]string, len(t.Header))
+		for i := range separators {
+			separators[i] = "---"
+		}
+		writeMarkdownRow(&b, separators)
+		for _, row := range t.Rows {
+			writeMarkdownRow(&b, row)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+/* Internal function: "| a | b |" with the pipes of the cells escaped */
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, cell := range cells {
+		b.WriteString(" " + strings.ReplaceAll(cell, "|", `\|`) + " |")
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,274 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"hw8-onlinestore/loadgen"
+)
+
+// define Dataset struct (the records of one backend, from one or more result files)
+type Dataset struct {
+	Backend string
+	Records []loadgen.Record
+}
+
+// define Stats struct (response times in ms and success rate of a set of records, with 95% confidence intervals)
+type Stats struct {
+	Operation     string // "all" for every operation of the backend
+	Count         int
+	Successes     int
+	SuccessRate   float64 // percent
+	SuccessRateCI Interval
+	Mean          float64
+	MeanCI        Interval
+	StdDev        float64
+	Min           float64
+	Max           float64
+	P50           float64
+	P50CI         Interval
+	P95           float64
+	P95CI         Interval
+	P99           float64
+	P99CI         Interval
+	Histogram     []int // counts in the buckets of Report.BucketEdges
+}
+
+// define ThroughputPoint struct (requests sent during one interval, Offset from the first record of the backend)
+type ThroughputPoint struct {
+	Offset    time.Duration
+	Requests  int
+	Successes int
+}
+
+// define BackendReport struct
+type BackendReport struct {
+	Backend    string
+	Start      time.Time
+	Duration   time.Duration // first to last record
+	Throughput float64       // requests per second over Duration (0 if all records have the same timestamp)
+	All        Stats
+	Operations []Stats // sorted by operation name
+	Timeline   []ThroughputPoint
+}
+
+// define Report struct (every backend, with histograms on common buckets so they can be compared)
+type Report struct {
+	Title       string
+	Generated   time.Time
+	Interval    time.Duration // width of a throughput point
+	BucketEdges []float64     // histogram bucket i is [BucketEdges[i], BucketEdges[i+1]) ms
+	Operations  []string      // operations of all backends, sorted
+	Backends    []BackendReport
+}
+
+/* LoadFile reads a result file: a JSON array of records (loadgen or the Python performance tests) tagged with backend,
+ * or, if backend is empty, an object of arrays keyed by backend (combined_results.json) */
+func LoadFile(path string, backend string) ([]Dataset, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if backend != "" {
+		records, err := loadgen.ReadRecords(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return []Dataset{{Backend: backend, Records: records}}, nil
+	}
+
+	var combined map[string]json.RawMessage
+	if err := json.Unmarshal(content, &combined); err != nil {
+		return nil, fmt.Errorf("%s is not an object of record arrays keyed by backend, tag it as backend=%s: %w", path, path, err)
+	}
+	datasets := make([]Dataset, 0, len(combined))
+	for name, value := range combined {
+		if !bytes.HasPrefix(bytes.TrimSpace(value), []byte("[")) {
+			continue // e.g. the metadata of combined_results.json
+		}
+		records, err := loadgen.ReadRecords(bytes.NewReader(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s, backend %s: %w", path, name, err)
+		}
+		datasets = append(datasets, Dataset{Backend: name, Records: records})
+	}
+	if len(datasets) == 0 {
+		return nil, fmt.Errorf("%s has no record array, tag a record array file as backend=%s", path, path)
+	}
+	sort.Slice(datasets, func(i, j int) bool { return datasets[i].Backend < datasets[j].Backend })
+	return datasets, nil
+}
+
+/* Merge appends the records of datasets with the same backend, keeping the order in which backends first appear */
+func Merge(datasets []Dataset) []Dataset {
+	merged := make([]Dataset, 0, len(datasets))
+	index := make(map[string]int)
+	for _, d := range datasets {
+		if i, ok := index[d.Backend]; ok {
+			merged[i].Records = append(merged[i].Records, d.Records...)
+			continue
+		}
+		index[d.Backend] = len(merged)
+		merged = append(merged, Dataset{Backend: d.Backend, Records: append([]loadgen.Record(nil), d.Records...)})
+	}
+	return merged
+}
+
+/* NormalizeOperation maps the operation names used by the different test scripts
+ * (e.g. CREATE_CART, createCart, retrieve_cart) to the loadgen names, like the Python analysis did */
+func NormalizeOperation(op string) string {
+	lower := strings.ToLower(op)
+	switch {
+	case strings.Contains(lower, "create"):
+		return loadgen.OpCreateCart
+	case strings.Contains(lower, "add"):
+		return loadgen.OpAddItems
+	case strings.Contains(lower, "get"), strings.Contains(lower, "retrieve"), strings.Contains(lower, "read"):
+		return loadgen.OpGetCart
+	}
+	return lower
+}
+
+/* Build computes the report of merged datasets; interval is the width of the throughput points */
+func Build(title string, datasets []Dataset, interval time.Duration) (Report, error) {
+	if len(datasets) == 0 {
+		return Report{}, fmt.Errorf("no result file given")
+	}
+	if interval <= 0 {
+		return Report{}, fmt.Errorf("throughput interval must be > 0 (input: %s)", interval)
+	}
+
+	// common histogram buckets for every backend (non-finite response times are left out of the bucket range,
+	// the histogram counts them in the last bucket)
+	lowest, highest := -1.0, 0.0
+	operations := make(map[string]bool)
+	for _, d := range datasets {
+		if len(d.Records) == 0 {
+			return Report{}, fmt.Errorf("backend %s has no records", d.Backend)
+		}
+		for _, r := range d.Records {
+			operations[NormalizeOperation(r.Operation)] = true
+			if math.IsNaN(r.ResponseTime) || math.IsInf(r.ResponseTime, 0) {
+				continue
+			}
+			if lowest < 0 || r.ResponseTime < lowest {
+				lowest = r.ResponseTime
+			}
+			highest = max(highest, r.ResponseTime)
+		}
+	}
+
+	report := Report{
+		Title:       title,
+		Generated:   time.Now().UTC(),
+		Interval:    interval,
+		BucketEdges: bucketEdges(lowest, highest),
+		Operations:  make([]string, 0, len(operations)),
+		Backends:    make([]BackendReport, 0, len(datasets)),
+	}
+	for op := range operations {
+		report.Operations = append(report.Operations, op)
+	}
+	sort.Strings(report.Operations)
+
+	for _, d := range datasets {
+		report.Backends = append(report.Backends, buildBackend(d, report.Operations, report.BucketEdges, interval))
+	}
+	return report, nil
+}
+
+/* Internal function: statistics of one backend, overall and per operation, and its throughput timeline */
+func buildBackend(d Dataset, operations []string, edges []float64, interval time.Duration) BackendReport {
+	byOp := make(map[string][]loadgen.Record)
+	start, end := d.Records[0].Timestamp, d.Records[0].Timestamp
+	for _, r := range d.Records {
+		op := NormalizeOperation(r.Operation)
+		byOp[op] = append(byOp[op], r)
+		if r.Timestamp.Before(start) {
+			start = r.Timestamp
+		}
+		if r.Timestamp.After(end) {
+			end = r.Timestamp
+		}
+	}
+
+	backend := BackendReport{
+		Backend:  d.Backend,
+		Start:    start,
+		Duration: end.Sub(start),
+		All:      computeStats("all", d.Records, edges),
+	}
+	if backend.Duration > 0 {
+		backend.Throughput = float64(len(d.Records)) / backend.Duration.Seconds()
+	}
+	for _, op := range operations {
+		if records, ok := byOp[op]; ok {
+			backend.Operations = append(backend.Operations, computeStats(op, records, edges))
+		}
+	}
+
+	// requests per interval, empty intervals included so that stalls show up
+	backend.Timeline = make([]ThroughputPoint, int(backend.Duration/interval)+1)
+	for i := range backend.Timeline {
+		backend.Timeline[i].Offset = time.Duration(i) * interval
+	}
+	for _, r := range d.Records {
+		point := &backend.Timeline[int(r.Timestamp.Sub(start)/interval)]
+		point.Requests++
+		if r.Success {
+			point.Successes++
+		}
+	}
+	return backend
+}
+
+/* Internal function: statistics of a set of records */
+func computeStats(operation string, records []loadgen.Record, edges []float64) Stats {
+	times := make([]float64, len(records))
+	successes := 0
+	for i, r := range records {
+		times[i] = r.ResponseTime
+		if r.Success {
+			successes++
+		}
+	}
+	sort.Float64s(times)
+
+	mean, stdDev := meanStdDev(times)
+	return Stats{
+		Operation:     operation,
+		Count:         len(records),
+		Successes:     successes,
+		SuccessRate:   100 * float64(successes) / float64(len(records)),
+		SuccessRateCI: wilsonInterval(successes, len(records)),
+		Mean:          mean,
+		MeanCI:        meanInterval(mean, stdDev, len(times)),
+		StdDev:        stdDev,
+		Min:           times[0],
+		Max:           times[len(times)-1],
+		P50:           percentile(times, 50),
+		P50CI:         percentileInterval(times, 50),
+		P95:           percentile(times, 95),
+		P95CI:         percentileInterval(times, 95),
+		P99:           percentile(times, 99),
+		P99CI:         percentileInterval(times, 99),
+		Histogram:     histogram(times, edges),
+	}
+}
+
+/* Operation returns the statistics of op, false if the backend has no record of it */
+func (b BackendReport) Operation(op string) (Stats, bool) {
+	for _, s := range b.Operations {
+		if s.Operation == op {
+			return s, true
+		}
+	}
+	return Stats{}, false
+}
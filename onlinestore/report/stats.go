@@ -0,0 +1,136 @@
+package report
+
+import (
+	"math"
+	"sort"
+)
+
+// 95% confidence level: z quantile of the normal distribution
+const z95 = 1.959964
+
+// t quantiles (two-sided 95%) of Student's distribution for 1..30 degrees of freedom, z95 is used above
+var t95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// define Interval struct (a 95% confidence interval)
+type Interval struct {
+	Low  float64
+	High float64
+}
+
+/* Internal function: percentile p (0..100) of sorted values with linear interpolation between the closest ranks
+ * (numpy.percentile's default, so the numbers match the Python analysis) */
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+/* Internal function: distribution-free confidence interval of percentile p, between the order statistics
+ * whose ranks are n*q -/+ z*sqrt(n*q*(1-q)) (normal approximation of the binomial distribution) */
+func percentileInterval(sorted []float64, p float64) Interval {
+	n := float64(len(sorted))
+	if n == 0 {
+		return Interval{}
+	}
+	q := p / 100
+	spread := z95 * math.Sqrt(n*q*(1-q))
+	low := int(math.Floor(n*q-spread)) - 1
+	high := int(math.Ceil(n*q+spread)) - 1
+	low = max(0, min(low, len(sorted)-1))
+	high = max(0, min(high, len(sorted)-1))
+	return Interval{Low: sorted[low], High: sorted[high]}
+}
+
+/* Internal function: mean and sample standard deviation */
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) == 1 {
+		return mean, 0
+	}
+	squares := 0.0
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
+
+/* Internal function: Student's t confidence interval of the mean */
+func meanInterval(mean float64, stdDev float64, n int) Interval {
+	if n < 2 {
+		return Interval{Low: mean, High: mean}
+	}
+	t := z95
+	if n-1 <= len(t95) {
+		t = t95[n-2]
+	}
+	margin := t * stdDev / math.Sqrt(float64(n))
+	return Interval{Low: mean - margin, High: mean + margin}
+}
+
+/* Internal function: Wilson score interval of a success rate, in percent
+ * (stays inside 0..100 and is usable for rates close to 0% or 100%, unlike the normal approximation) */
+func wilsonInterval(successes int, n int) Interval {
+	if n == 0 {
+		return Interval{}
+	}
+	total := float64(n)
+	rate := float64(successes) / total
+	z2 := z95 * z95
+	center := (rate + z2/(2*total)) / (1 + z2/total)
+	margin := z95 / (1 + z2/total) * math.Sqrt(rate*(1-rate)/total+z2/(4*total*total))
+	return Interval{Low: 100 * max(0, center-margin), High: 100 * min(1, center+margin)}
+}
+
+/* Internal function: histogram bucket edges on the 1-2-5 series (0.1, 0.2, 0.5, 1, 2, 5, ... ms)
+ * from the largest edge <= lowest to the smallest edge > highest
+ * (the series ends before the edges overflow, so a non-finite highest can not loop forever) */
+func bucketEdges(lowest float64, highest float64) []float64 {
+	edges := make([]float64, 0)
+	steps := []float64{1, 2, 5}
+	for scale := 0.1; scale*steps[len(steps)-1] <= math.MaxFloat64; scale *= 10 {
+		for _, step := range steps {
+			edge := step * scale
+			if scale < 1 {
+				edge = math.Round(edge*1000) / 1000 // 0.1 * 2 is not exactly 0.2
+			}
+			if len(edges) == 0 || edge <= lowest {
+				edges = []float64{edge}
+				continue
+			}
+			edges = append(edges, edge)
+			if edge > highest {
+				return edges
+			}
+		}
+	}
+	return edges
+}
+
+/* Internal function: number of values in every bucket [edges[i], edges[i+1]) */
+func histogram(values []float64, edges []float64) []int {
+	counts := make([]int, len(edges)-1)
+	for _, v := range values {
+		i := sort.SearchFloat64s(edges, v)
+		// SearchFloat64s returns the index of the first edge >= v: v belongs to the bucket before it unless v is that edge
+		if i == len(edges) || edges[i] != v {
+			i--
+		}
+		counts[max(0, min(i, len(counts)-1))]++
+	}
+	return counts
+}
@@ -0,0 +1,137 @@
+package report
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"hw8-onlinestore/loadgen"
+)
+
+/* Internal function: compare floats up to rounding errors */
+func almostEqual(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestPercentile(t *testing.T) {
+	// expected values are numpy.percentile(values, p) (linear interpolation)
+	tests := []struct {
+		name   string
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{"empty", nil, 50, 0},
+		{"single value", []float64{7}, 99, 7},
+		{"median of even count", []float64{1, 2, 3, 4}, 50, 2.5},
+		{"p95 of four", []float64{1, 2, 3, 4}, 95, 3.85},
+		{"p99 of four", []float64{1, 2, 3, 4}, 99, 3.97},
+		{"p40 of five", []float64{15, 20, 35, 40, 50}, 40, 29},
+		{"p0 is the minimum", []float64{15, 20, 35, 40, 50}, 0, 15},
+		{"p100 is the maximum", []float64{15, 20, 35, 40, 50}, 100, 50},
+		{"long tail", []float64{0.8, 1.2, 3.5, 10.1, 250}, 95, 202.02},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.values, tt.p); !almostEqual(got, tt.want) {
+				t.Fatalf("percentile(%v, %g) = %g, want %g", tt.values, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name              string
+		successes, n      int
+		wantLow, wantHigh float64
+	}{
+		{"no records", 0, 0, 0, 0},
+		{"0%", 0, 100, 0, 3.699350},
+		{"100%", 100, 100, 96.300650, 100},
+		{"50%", 50, 100, 40.383153, 59.616847},
+		{"0% of few records", 0, 10, 0, 27.753280},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wilsonInterval(tt.successes, tt.n)
+			if !almostEqual(got.Low, tt.wantLow) || !almostEqual(got.High, tt.wantHigh) {
+				t.Fatalf("wilsonInterval(%d, %d) = %+v, want {Low:%g High:%g}", tt.successes, tt.n, got, tt.wantLow, tt.wantHigh)
+			}
+		})
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	edges := []float64{1, 2, 5, 10}
+	tests := []struct {
+		name   string
+		values []float64
+		want   []int
+	}{
+		{"lower edge is included", []float64{1, 2, 5}, []int{1, 1, 1}},
+		{"upper edge is excluded", []float64{1.999, 4.999}, []int{1, 1, 0}},
+		{"below the first edge", []float64{0.5}, []int{1, 0, 0}},
+		{"last edge and above", []float64{10, 12}, []int{0, 0, 2}},
+		{"non-finite values", []float64{math.NaN(), math.Inf(1)}, []int{0, 0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := histogram(tt.values, edges); !slices.Equal(got, tt.want) {
+				t.Fatalf("histogram(%v, %v) = %v, want %v", tt.values, edges, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketEdges(t *testing.T) {
+	tests := []struct {
+		name            string
+		lowest, highest float64
+		want            []float64
+	}{
+		{"values on edges", 1, 5, []float64{1, 2, 5, 10}},
+		{"values between edges", 1.5, 4, []float64{1, 2, 5}},
+		{"below the first edge", 0.05, 0.1, []float64{0.1, 0.2}},
+		{"one value", 0.3, 0.3, []float64{0.2, 0.5}},
+		{"large values", 900, 20000, []float64{500, 1000, 2000, 5000, 10000, 20000, 50000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bucketEdges(tt.lowest, tt.highest); !slices.Equal(got, tt.want) {
+				t.Fatalf("bucketEdges(%g, %g) = %v, want %v", tt.lowest, tt.highest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketEdgesNonFinite(t *testing.T) {
+	for _, highest := range []float64{math.NaN(), math.Inf(1), math.MaxFloat64} {
+		edges := bucketEdges(1, highest)
+		if len(edges) < 2 {
+			t.Fatalf("bucketEdges(1, %g) = %v, want at least one bucket", highest, edges)
+		}
+		for _, edge := range edges {
+			if math.IsInf(edge, 0) || math.IsNaN(edge) {
+				t.Fatalf("bucketEdges(1, %g) has the non-finite edge %g", highest, edge)
+			}
+		}
+	}
+}
+
+func TestBuildIgnoresNonFiniteResponseTimes(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []loadgen.Record{
+		{Operation: loadgen.OpGetCart, ResponseTime: 3, Success: true, Timestamp: start},
+		{Operation: loadgen.OpGetCart, ResponseTime: math.NaN(), Timestamp: start.Add(time.Second)},
+		{Operation: loadgen.OpGetCart, ResponseTime: math.Inf(1), Timestamp: start.Add(2 * time.Second)},
+	}
+	report, err := Build("report", []Dataset{{Backend: "memory", Records: records}}, time.Second)
+	if err != nil {
+		t.Fatalf("failed to build the report: %v", err)
+	}
+	if !slices.Equal(report.BucketEdges, []float64{2, 5}) {
+		t.Fatalf("expected the buckets of the finite response time, got %v", report.BucketEdges)
+	}
+}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+)
+
+// define table struct (a section of the Markdown and HTML reports)
+type table struct {
+	Title  string
+	Note   string
+	Header []string
+	Rows   [][]string
+	chart  func() template.HTML // drawn above the table in the HTML report
+}
+
+/* Internal function: the tables of the report, in the order they are rendered */
+func (r Report) tables() []table {
+	return []table{r.summaryTable(), r.operationTable(), r.intervalTable(), r.histogramTable(), r.throughputTable()}
+}
+
+/* Internal function: one column per backend, plus the winner and its margin over the runner-up when backends are compared
+ * (the comparison table of the Python analysis) */
+func (r Report) summaryTable() table {
+	t := table{Title: "Comparison", Header: []string{"Metric"}}
+	for _, b := range r.Backends {
+		t.Header = append(t.Header, b.Backend)
+	}
+	compare := len(r.Backends) > 1
+	if compare {
+		t.Header = append(t.Header, "Winner", "Margin")
+	}
+
+	type metric struct {
+		name          string
+		value         func(b BackendReport) float64
+		format        string
+		lowerIsBetter bool
+		unit          string
+	}
+	metrics := []metric{
+		{"Avg Response Time (ms)", func(b BackendReport) float64 { return b.All.Mean }, "%.2f", true, " ms"},
+		{"P50 Response Time (ms)", func(b BackendReport) float64 { return b.All.P50 }, "%.2f", true, " ms"},
+		{"P95 Response Time (ms)", func(b BackendReport) float64 { return b.All.P95 }, "%.2f", true, " ms"},
+		{"P99 Response Time (ms)", func(b BackendReport) float64 { return b.All.P99 }, "%.2f", true, " ms"},
+		{"Success Rate (%)", func(b BackendReport) float64 { return b.All.SuccessRate }, "%.2f", false, "%"},
+		{"Throughput (requests/s)", func(b BackendReport) float64 { return b.Throughput }, "%.1f", false, " requests/s"},
+		{"Total Operations", func(b BackendReport) float64 { return float64(b.All.Count) }, "%.0f", false, ""},
+	}
+	for _, m := range metrics {
+		row := []string{m.name}
+		values := make([]float64, len(r.Backends))
+		for i, b := range r.Backends {
+			values[i] = m.value(b)
+			row = append(row, fmt.Sprintf(m.format, values[i]))
+		}
+		if compare {
+			if m.unit == "" {
+				row = append(row, "-", "-") // operation counts are not a result
+			} else {
+				winner, margin := r.winner(values, m.lowerIsBetter)
+				row = append(row, winner, fmt.Sprintf(m.format, margin)+m.unit)
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+/* Internal function: mean response time of every operation per backend and the fastest backend */
+func (r Report) operationTable() table {
+	t := table{Title: "Operation breakdown", Header: []string{"Operation"}}
+	for _, b := range r.Backends {
+		t.Header = append(t.Header, b.Backend+" avg (ms)", b.Backend+" p95 (ms)")
+	}
+	compare := len(r.Backends) > 1
+	if compare {
+		t.Header = append(t.Header, "Faster")
+	}
+
+	for _, op := range r.Operations {
+		row := []string{op}
+		means := make([]float64, len(r.Backends))
+		missing := false
+		for i, b := range r.Backends {
+			s, ok := b.Operation(op)
+			if !ok {
+				missing = true
+				row = append(row, "-", "-")
+				continue
+			}
+			means[i] = s.Mean
+			row = append(row, fmt.Sprintf("%.2f", s.Mean), fmt.Sprintf("%.2f", s.P95))
+		}
+		if compare {
+			if missing {
+				row = append(row, "Data missing")
+			} else {
+				winner, margin := r.winner(means, true)
+				row = append(row, fmt.Sprintf("%s by %.2f ms", winner, margin))
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+/* Internal function: estimates with their 95% confidence intervals, per backend and operation */
+func (r Report) intervalTable() table {
+	t := table{
+		Title: "95% confidence intervals",
+		Note: "Mean: Student's t interval. Percentiles: distribution-free interval between order statistics. " +
+			"Success rate: Wilson score interval. Intervals that do not overlap indicate a real difference between backends.",
+		Header: []string{"Backend", "Operation", "Requests", "Mean (ms)", "P50 (ms)", "P95 (ms)", "P99 (ms)", "Success rate (%)"},
+	}
+	for _, b := range r.Backends {
+		for _, s := range append([]Stats{b.All}, b.Operations...) {
+			t.Rows = append(t.Rows, []string{
+				b.Backend, s.Operation, strconv.Itoa(s.Count),
+				formatEstimate(s.Mean, s.MeanCI), formatEstimate(s.P50, s.P50CI), formatEstimate(s.P95, s.P95CI),
+				formatEstimate(s.P99, s.P99CI), formatEstimate(s.SuccessRate, s.SuccessRateCI),
+			})
+		}
+	}
+	return t
+}
+
+/* Internal function: response time distribution of every backend on the common buckets, with the share of requests */
+func (r Report) histogramTable() table {
+	t := table{Title: "Response time histogram", Header: []string{"Response time (ms)"}, chart: r.histogramChart}
+	for _, b := range r.Backends {
+		t.Header = append(t.Header, b.Backend)
+	}
+	for i := range len(r.BucketEdges) - 1 {
+		row := []string{r.bucketLabel(i)}
+		for _, b := range r.Backends {
+			count := b.All.Histogram[i]
+			row = append(row, fmt.Sprintf("%d (%.1f%%)", count, 100*float64(count)/float64(b.All.Count)))
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+/* Internal function: requests per second of every backend over time, from the first record of each backend */
+func (r Report) throughputTable() table {
+	t := table{
+		Title:  "Throughput over time",
+		Note:   fmt.Sprintf("Requests per second (successful requests in parentheses) in %s intervals.", r.Interval),
+		Header: []string{"Time (s)"},
+		chart:  r.throughputChart,
+	}
+	points := 0
+	for _, b := range r.Backends {
+		t.Header = append(t.Header, b.Backend)
+		points = max(points, len(b.Timeline))
+	}
+	seconds := r.Interval.Seconds()
+	for i := range points {
+		row := []string{strconv.FormatFloat(float64(i)*seconds, 'f', -1, 64)}
+		for _, b := range r.Backends {
+			if i >= len(b.Timeline) {
+				row = append(row, "")
+				continue
+			}
+			p := b.Timeline[i]
+			row = append(row, fmt.Sprintf("%.1f (%.1f)", float64(p.Requests)/seconds, float64(p.Successes)/seconds))
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+/* Internal function: best backend for values and its margin over the second best */
+func (r Report) winner(values []float64, lowerIsBetter bool) (string, float64) {
+	best, second := -1, -1
+	better := func(a, b float64) bool {
+		if lowerIsBetter {
+			return a < b
+		}
+		return a > b
+	}
+	for i, v := range values {
+		switch {
+		case best < 0 || better(v, values[best]):
+			best, second = i, best
+		case second < 0 || better(v, values[second]):
+			second = i
+		}
+	}
+	if second < 0 {
+		return r.Backends[best].Backend, 0
+	}
+	if values[best] == values[second] {
+		return "Tie", 0
+	}
+	return r.Backends[best].Backend, math.Abs(values[best] - values[second])
+}
+
+/* Internal function: "low - high" label of histogram bucket i (low included, high excluded) */
+func (r Report) bucketLabel(i int) string {
+	return fmt.Sprintf("%g - %g", r.BucketEdges[i], r.BucketEdges[i+1])
+}
+
+/* Internal function: "12.34 [11.02, 13.80]" */
+func formatEstimate(value float64, ci Interval) string {
+	return fmt.Sprintf("%.2f [%.2f, %.2f]", value, ci.Low, ci.High)
+}
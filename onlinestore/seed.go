@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+
+	"hw8-onlinestore/setup"
+	"hw8-onlinestore/store"
+)
+
+// define seedOptions struct (flags of the seed subcommand)
+type seedOptions struct {
+	products  store.ProductSpec
+	inventory int // number of products given a stock
+	maxStock  int // stock is drawn from 0..maxStock
+	carts     int // number of customers (1..carts) given an active cart
+	cartItems int // items per cart are drawn from 1..cartItems
+}
+
+/* Internal function: default seed, the catalog the server used to generate at startup */
+func defaultSeedOptions() seedOptions {
+	return seedOptions{
+		products:  store.ProductSpec{Count: DataSize, Seed: 1},
+		maxStock:  100,
+		cartItems: 5,
+	}
+}
+
+/* runSeed implements "online-store seed [flags]": fill an empty catalog with generated products,
+ * then optionally give products a stock and customers an active cart */
+func runSeed(ctx context.Context, cfg setup.Config, args []string) error {
+	opts := defaultSeedOptions()
+	var brands, categories string
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	flags.IntVar(&opts.products.Count, "products", opts.products.Count, "number of products to generate (IDs 1..n)")
+	flags.StringVar(&brands, "brands", strings.Join(store.DefaultBrands, ","), "comma-separated brands of the generated products")
+	flags.StringVar(&categories, "categories", strings.Join(store.DefaultCategories, ","), "comma-separated categories of the generated products")
+	flags.Uint64Var(&opts.products.Seed, "seed", opts.products.Seed, "random seed (the same seed generates the same data)")
+	flags.IntVar(&opts.inventory, "inventory", 0, "number of products given a random stock (MySQL only)")
+	flags.IntVar(&opts.maxStock, "max-stock", opts.maxStock, "largest stock given to a product")
+	flags.IntVar(&opts.carts, "carts", 0, "number of customers given an active cart with random items")
+	flags.IntVar(&opts.cartItems, "cart-items", opts.cartItems, "largest number of items in a generated cart")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: online-store seed [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	opts.products.Brands = splitList(brands)
+	opts.products.Categories = splitList(categories)
+	switch {
+	case opts.products.Count < 1:
+		return errors.New("-products must be >= 1")
+	case len(opts.products.Brands) == 0 || len(opts.products.Categories) == 0:
+		return errors.New("-brands and -categories need at least one value")
+	case opts.inventory < 0 || opts.inventory > opts.products.Count:
+		return fmt.Errorf("-inventory must be between 0 and the number of products (%d)", opts.products.Count)
+	case opts.maxStock < 0 || opts.carts < 0 || opts.cartItems < 1:
+		return errors.New("-max-stock and -carts must be >= 0, -cart-items >= 1")
+	}
+	if cfg.DatabaseType == setup.DatabaseMemory {
+		return errors.New("the memory backend lives in the server process, it is seeded at startup")
+	}
+
+	backend, err := setup.OpenBackend(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer backend.Close()
+	return seed(ctx, backend, opts)
+}
+
+/* Internal function: seed a backend, the products are only stored if the catalog is empty
+ * (inventory and carts refer to the product IDs 1..n of the generated products) */
+func seed(ctx context.Context, backend *setup.Backend, opts seedOptions) error {
+	seeder, ok := backend.Products.(store.Seeder)
+	if !ok {
+		return errors.New("the backend can not be seeded")
+	}
+	seeded, err := seeder.SeedIfEmpty(ctx, store.GenerateProducts(opts.products))
+	if err != nil {
+		return err
+	}
+	if !seeded {
+		slog.Info("the catalog already has products, no product generated")
+	}
+
+	rng := rand.New(rand.NewPCG(opts.products.Seed, opts.products.Seed+1))
+	if opts.inventory > 0 {
+		inventory, ok := backend.Carts.(store.InventoryStore)
+		if !ok {
+			return errors.New("the backend does not track inventory, -inventory can not be used")
+		}
+		for _, i := range rng.Perm(opts.products.Count)[:opts.inventory] {
+			if _, err := inventory.SetStock(ctx, int32(i+1), uint(rng.IntN(opts.maxStock+1))); err != nil {
+				return fmt.Errorf("failed to set the stock of product %d: %w", i+1, err)
+			}
+		}
+		slog.Info("stock set", "products", opts.inventory)
+	}
+
+	created := 0
+	for customerID := 1; customerID <= opts.carts; customerID++ {
+		cart, err := backend.Carts.Create(ctx, uint64(customerID))
+		var activeCart *store.ActiveCartExistsError
+		if errors.As(err, &activeCart) {
+			continue // seeded before
+		} else if err != nil {
+			return fmt.Errorf("failed to create a cart for customer %d: %w", customerID, err)
+		}
+
+		// distinct random products, 1 to 3 units each
+		count := min(1+rng.IntN(opts.cartItems), opts.products.Count)
+		picked := make(map[int32]bool, count)
+		items := make([]store.ItemUpdate, 0, count)
+		for len(items) < count {
+			productID := int32(1 + rng.IntN(opts.products.Count))
+			if !picked[productID] {
+				picked[productID] = true
+				items = append(items, store.ItemUpdate{ProductID: productID, Quantity: uint(1 + rng.IntN(3))})
+			}
+		}
+		var insufficientStock *store.InsufficientStockError
+		if _, err := backend.Carts.UpsertItems(ctx, cart.CartID, items, 0); errors.As(err, &insufficientStock) {
+			slog.Warn("cart left empty", "cart_id", cart.CartID, "customer_id", customerID, "error", err)
+		} else if err != nil {
+			return fmt.Errorf("failed to add items to cart %s: %w", cart.CartID, err)
+		}
+		created++
+	}
+	if opts.carts > 0 {
+		slog.Info("active carts created", "carts", created)
+	}
+	return nil
+}
+
+/* Internal function: split a comma-separated flag value, dropping empty entries */
+func splitList(value string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
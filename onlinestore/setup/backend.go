@@ -0,0 +1,81 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+
+	"hw8-onlinestore/metrics"
+	"hw8-onlinestore/store"
+	"hw8-onlinestore/store/dynamostore"
+	"hw8-onlinestore/store/memstore"
+	"hw8-onlinestore/store/mysqlstore"
+	"hw8-onlinestore/tracing"
+)
+
+/* Backend is the store selected by DATABASE_TYPE */
+type Backend struct {
+	Carts    store.CartStore
+	Products store.ProductStore // nil if the backend has no product catalog
+	close    func() error
+}
+
+/* Close releases the backend's connections */
+func (b *Backend) Close() error {
+	if b.close == nil {
+		return nil
+	}
+	return b.close()
+}
+
+/* OpenBackend connects to the backend selected by cfg.DatabaseType */
+func OpenBackend(ctx context.Context, cfg Config) (*Backend, error) {
+	switch cfg.DatabaseType {
+	case DatabaseMySQL:
+		s, err := mysqlstore.Open(ctx, cfg.MySQL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize MySQL: %w", err)
+		}
+		return &Backend{Carts: s, Products: s, close: s.Close}, nil
+
+	case DatabaseDynamoDB:
+		awsCfg, err := AWSConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		slog.InfoContext(ctx, "using DynamoDB", "table", cfg.DynamoDBTable)
+		s := dynamostore.New(dynamodb.NewFromConfig(awsCfg, metrics.InstrumentDynamoDB), cfg.DynamoDBTable)
+		return &Backend{Carts: s, Products: s}, nil
+
+	case DatabaseMemory:
+		// no external services: everything lives in process memory and is lost on exit
+		s := memstore.New()
+		return &Backend{Carts: s, Products: s}, nil
+	}
+	return nil, fmt.Errorf("unsupported DATABASE_TYPE %q", cfg.DatabaseType)
+}
+
+// AWS config is loaded once, and only when an AWS service is used
+var (
+	awsOnce sync.Once
+	awsCfg  aws.Config
+	awsErr  error
+)
+
+/* AWSConfig returns the default AWS configuration (environment, shared files or task role) */
+func AWSConfig(ctx context.Context) (aws.Config, error) {
+	awsOnce.Do(func() {
+		// every client built from it (DynamoDB, SNS, SQS) traces its calls
+		awsCfg, awsErr = config.LoadDefaultConfig(ctx, config.WithAPIOptions([]func(*middleware.Stack) error{tracing.InstrumentAWS}))
+		if awsErr != nil {
+			awsErr = fmt.Errorf("unable to load AWS config: %w", awsErr)
+		}
+	})
+	return awsCfg, awsErr
+}
@@ -0,0 +1,89 @@
+package setup
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"hw8-onlinestore/store/mysqlstore"
+)
+
+// supported DATABASE_TYPE values
+const (
+	DatabaseMySQL    = "mysql"
+	DatabaseDynamoDB = "dynamodb"
+	DatabaseMemory   = "memory"
+)
+
+// define Config struct (read from the environment at startup, shared by the server and the order worker)
+type Config struct {
+	DatabaseType  string
+	Addr          string
+	MySQL         mysqlstore.Config
+	DynamoDBTable string
+
+	OrderEventsTopicARN string
+	OrderQueueURL       string
+	WorkerConcurrency   int
+
+	IdempotencyTTL time.Duration
+}
+
+/* LoadConfig reads the configuration from the environment
+ *  - DATABASE_TYPE        mysql (default) | dynamodb | memory
+ *  - PORT                 listen port (default 8080)
+ *  - DB_USERNAME, DB_PASSWORD, DB_HOST, DB_PORT, DB_NAME  (mysql)
+ *  - DYNAMODB_TABLE_NAME  (dynamodb)
+ *  - ORDER_EVENTS_TOPIC_ARN  SNS topic for OrderPlaced events (unset: in-process fake publisher)
+ *  - ORDER_QUEUE_URL      SQS queue consumed by the order worker
+ *  - WORKER_CONCURRENCY   messages processed in parallel by the order worker (default 4)
+ *  - IDEMPOTENCY_TTL      how long Idempotency-Key responses are replayed, Go duration (default 24h) */
+func LoadConfig() (Config, error) {
+	cfg := Config{
+		DatabaseType: getenv("DATABASE_TYPE", DatabaseMySQL),
+		Addr:         ":" + getenv("PORT", "8080"),
+		MySQL: mysqlstore.Config{
+			Username: os.Getenv("DB_USERNAME"),
+			Password: os.Getenv("DB_PASSWORD"),
+			Host:     os.Getenv("DB_HOST"),
+			Port:     os.Getenv("DB_PORT"),
+			Name:     os.Getenv("DB_NAME"),
+		},
+		DynamoDBTable: os.Getenv("DYNAMODB_TABLE_NAME"),
+
+		OrderEventsTopicARN: os.Getenv("ORDER_EVENTS_TOPIC_ARN"),
+		OrderQueueURL:       os.Getenv("ORDER_QUEUE_URL"),
+	}
+
+	concurrency, err := strconv.Atoi(getenv("WORKER_CONCURRENCY", "4"))
+	if err != nil || concurrency < 1 {
+		return cfg, fmt.Errorf("WORKER_CONCURRENCY must be a positive integer (input: %s)", os.Getenv("WORKER_CONCURRENCY"))
+	}
+	cfg.WorkerConcurrency = concurrency
+
+	ttl, err := time.ParseDuration(getenv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || ttl <= 0 {
+		return cfg, fmt.Errorf("IDEMPOTENCY_TTL must be a positive duration such as 24h (input: %s)", os.Getenv("IDEMPOTENCY_TTL"))
+	}
+	cfg.IdempotencyTTL = ttl
+
+	switch cfg.DatabaseType {
+	case DatabaseMySQL, DatabaseMemory:
+	case DatabaseDynamoDB:
+		if cfg.DynamoDBTable == "" {
+			return cfg, fmt.Errorf("DYNAMODB_TABLE_NAME environment variable not set")
+		}
+	default:
+		return cfg, fmt.Errorf("unsupported DATABASE_TYPE %q (expected %q, %q or %q)", cfg.DatabaseType, DatabaseMySQL, DatabaseDynamoDB, DatabaseMemory)
+	}
+	return cfg, nil
+}
+
+/* Internal function: Read an environment variable with a fallback value */
+func getenv(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+/* EncodeCursor turns a backend position (e.g. the last cart_id or a DynamoDB LastEvaluatedKey)
+ * into an opaque URL-safe page token */
+func EncodeCursor(position any) (string, error) {
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+/* DecodeCursor reads a page token created by EncodeCursor into position, fails with ErrInvalidCursor */
+func DecodeCursor(cursor string, position any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+	}
+	if err := json.Unmarshal(raw, position); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+	}
+	return nil
+}
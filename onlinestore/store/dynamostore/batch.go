@@ -0,0 +1,66 @@
+package dynamostore
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"hw8-onlinestore/store"
+)
+
+// retry policy for the UnprocessedItems of BatchWriteItem
+const batchWriteAttempts = 8
+
+// backoff of retried writes (variables so that tests can shorten them)
+var (
+	batchWriteBaseDelay  = 50 * time.Millisecond
+	batchWriteMaxBackoff = 5 * time.Second
+)
+
+/* Internal function: write requests to the table in chunks of batchWriteLimit
+ * BatchWriteItem may accept only part of a chunk (throttling): the UnprocessedItems are sent again
+ * with jittered exponential backoff, and *store.PartialWriteError reports what is left after the last attempt. */
+func (s *Store) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	for i := 0; i < len(requests); i += batchWriteLimit {
+		chunk := requests[i:min(i+batchWriteLimit, len(requests))]
+
+		pending := chunk
+		for attempt := 1; len(pending) > 0; attempt++ {
+			if attempt > batchWriteAttempts {
+				// the remaining chunks are not sent either
+				written := i + len(chunk) - len(pending)
+				return &store.PartialWriteError{Written: written, Failed: len(requests) - written}
+			}
+			if attempt > 1 {
+				if err := sleepBackoff(ctx, attempt-1); err != nil {
+					return err
+				}
+			}
+
+			output, err := s.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]types.WriteRequest{s.tableName: pending},
+			})
+			if err != nil {
+				return err
+			}
+			pending = output.UnprocessedItems[s.tableName]
+		}
+	}
+	return nil
+}
+
+/* Internal function: wait a random delay between 0 and base * 2^retry, capped (full jitter) */
+func sleepBackoff(ctx context.Context, retry int) error {
+	backoff := min(batchWriteBaseDelay<<retry, batchWriteMaxBackoff)
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(backoff)) + 1))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
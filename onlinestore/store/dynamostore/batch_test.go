@@ -0,0 +1,134 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"hw8-onlinestore/store"
+)
+
+/* Internal function: n put requests, each item has a distinct PK */
+func putRequests(n int) []types.WriteRequest {
+	requests := make([]types.WriteRequest, 0, n)
+	for i := range n {
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("PRODUCT#%d", i)},
+		}}})
+	}
+	return requests
+}
+
+/* Internal function: PK of a put request */
+func requestPK(request types.WriteRequest) string {
+	return request.PutRequest.Item["PK"].(*types.AttributeValueMemberS).Value
+}
+
+/* Internal function: shorten the backoff of retried writes for one test */
+func shortBackoff(t *testing.T) {
+	base, maxBackoff := batchWriteBaseDelay, batchWriteMaxBackoff
+	batchWriteBaseDelay, batchWriteMaxBackoff = time.Microsecond, time.Millisecond
+	t.Cleanup(func() { batchWriteBaseDelay, batchWriteMaxBackoff = base, maxBackoff })
+}
+
+func TestBatchWriteRetriesUnprocessedItems(t *testing.T) {
+	shortBackoff(t)
+
+	// every first call of a chunk leaves its last 2 requests unprocessed
+	calls := make([][]string, 0)
+	written := make([]string, 0)
+	s := newTestStore(&fakeClient{
+		batchWriteItem: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			requests := input.RequestItems["test"]
+			pks := make([]string, 0, len(requests))
+			for _, request := range requests {
+				pks = append(pks, requestPK(request))
+			}
+			calls = append(calls, pks)
+
+			output := &dynamodb.BatchWriteItemOutput{}
+			if len(requests) > 2 {
+				output.UnprocessedItems = map[string][]types.WriteRequest{"test": requests[len(requests)-2:]}
+				requests = requests[:len(requests)-2]
+			}
+			for _, request := range requests {
+				written = append(written, requestPK(request))
+			}
+			return output, nil
+		},
+	})
+
+	requests := putRequests(60)
+	if err := s.batchWrite(context.Background(), requests); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	sizes := make([]int, 0, len(calls))
+	for _, call := range calls {
+		sizes = append(sizes, len(call))
+	}
+	// chunks of 25, 25 and 10, each followed by the retry of its 2 unprocessed requests
+	if want := []int{25, 2, 25, 2, 10, 2}; !slices.Equal(sizes, want) {
+		t.Fatalf("expected calls of %v requests, got %v", want, sizes)
+	}
+	if !slices.Equal(calls[1], calls[0][23:]) {
+		t.Fatalf("expected the retry to send the unprocessed requests %v, got %v", calls[0][23:], calls[1])
+	}
+	slices.Sort(written)
+	want := make([]string, 0, len(requests))
+	for _, request := range requests {
+		want = append(want, requestPK(request))
+	}
+	slices.Sort(want)
+	if !slices.Equal(written, want) {
+		t.Fatalf("expected every request to be written once, got %v", written)
+	}
+}
+
+func TestBatchWritePartialWrite(t *testing.T) {
+	shortBackoff(t)
+
+	// the first chunk is written, the second one keeps 5 requests unprocessed
+	calls := 0
+	s := newTestStore(&fakeClient{
+		batchWriteItem: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			calls++
+			requests := input.RequestItems["test"]
+			if calls == 1 {
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			}
+			return &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{"test": requests[max(0, len(requests)-5):]}}, nil
+		},
+	})
+
+	err := s.batchWrite(context.Background(), putRequests(60))
+	var partial *store.PartialWriteError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected a PartialWriteError, got %v", err)
+	}
+	// 25 + 20 written, 5 unprocessed and the third chunk of 10 was not sent
+	if partial.Written != 45 || partial.Failed != 15 {
+		t.Fatalf("expected 45 written and 15 failed, got %+v", partial)
+	}
+	if calls != 1+batchWriteAttempts {
+		t.Fatalf("expected %d calls, got %d", 1+batchWriteAttempts, calls)
+	}
+}
+
+func TestBatchWriteError(t *testing.T) {
+	failure := errors.New("throttled")
+	s := newTestStore(&fakeClient{
+		batchWriteItem: func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			return nil, failure
+		},
+	})
+	if err := s.batchWrite(context.Background(), putRequests(3)); !errors.Is(err, failure) {
+		t.Fatalf("expected the client error, got %v", err)
+	}
+}
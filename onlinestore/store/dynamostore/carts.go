@@ -0,0 +1,686 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+
+	"hw8-onlinestore/store"
+)
+
+/* Internal function: validate a cart ID (UUID) and return its partition key */
+func cartKey(cartID string) (string, error) {
+	if _, err := uuid.Parse(cartID); err != nil {
+		return "", fmt.Errorf("%w: shopping cart ID must be a UUID (input: %s)", store.ErrInvalidCartID, cartID)
+	}
+	return fmt.Sprintf("CART#%s", cartID), nil
+}
+
+// layout of the creation time in GSI1SK (fixed width, so the keys sort in creation order)
+const createdAtLayout = "2006-01-02T15:04:05.000000000Z"
+
+/* Internal function: GSI1 sort key of a cart, newest carts have the highest keys */
+func customerCartSortKey(createdAt time.Time, cartID string) string {
+	return fmt.Sprintf("%s#%s", createdAt.UTC().Format(createdAtLayout), cartID)
+}
+
+/* Internal function: key of the guard item holding the customer's active cart */
+func activeCartKey(customerID uint64) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("CUST#%d", customerID)},
+		"SK": &types.AttributeValueMemberS{Value: "ACTIVE_CART"},
+	}
+}
+
+/* Internal function: check if a transaction was cancelled because of the condition of its i-th item */
+func conditionFailed(reasons []types.CancellationReason, i int) bool {
+	return i < len(reasons) && aws.ToString(reasons[i].Code) == "ConditionalCheckFailed"
+}
+
+/*
+Create
+Creates a new shopping cart metadata row if the customer does not have an active shopping cart.
+The cart and the CUST#<customer_id> / ACTIVE_CART guard item are written in one transaction:
+the guard can only be created if it does not exist yet, which is the DynamoDB equivalent of MySQL's SELECT ... FOR UPDATE.
+*/
+func (s *Store) Create(ctx context.Context, customerID uint64) (store.Cart, error) {
+	cartID := uuid.New().String() // Our Cart ID is a string
+	cartPK := fmt.Sprintf("CART#%s", cartID)
+	custPK := fmt.Sprintf("CUST#%d", customerID)
+
+	meta := cartMetadata{
+		PK:         cartPK,
+		SK:         "CART",
+		GSI1PK:     custPK,
+		GSI1SK:     customerCartSortKey(time.Now(), cartID),
+		CartID:     cartID,
+		CustomerID: customerID,
+		Status:     store.CartStatusActive,
+		Version:    1,
+	}
+	guard := activeCartGuard{
+		PK:         custPK,
+		SK:         "ACTIVE_CART",
+		CartID:     cartID,
+		CustomerID: customerID,
+	}
+
+	metaItem, err := attributevalue.MarshalMap(meta)
+	if err != nil {
+		return store.Cart{}, fmt.Errorf("failed to marshal cart data: %w", err)
+	}
+	guardItem, err := attributevalue.MarshalMap(guard)
+	if err != nil {
+		return store.Cart{}, fmt.Errorf("failed to marshal cart data: %w", err)
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:                           aws.String(s.tableName),
+				Item:                                guardItem,
+				ConditionExpression:                 aws.String("attribute_not_exists(PK)"), // Fail if the customer has an active cart
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			}},
+			{Put: &types.Put{
+				TableName:           aws.String(s.tableName),
+				Item:                metaItem,
+				ConditionExpression: aws.String("attribute_not_exists(PK)"), // Fail if cart ID already exists
+			}},
+		},
+	})
+	if err != nil {
+		var cancelled *types.TransactionCanceledException
+		if !errors.As(err, &cancelled) {
+			return store.Cart{}, err
+		}
+		if conditionFailed(cancelled.CancellationReasons, 0) { // active cart already exists for this customer
+			var existing activeCartGuard
+			if err := attributevalue.UnmarshalMap(cancelled.CancellationReasons[0].Item, &existing); err != nil {
+				return store.Cart{}, err
+			}
+			return store.Cart{}, &store.ActiveCartExistsError{CustomerID: customerID, CartID: existing.CartID}
+		}
+		if conditionFailed(cancelled.CancellationReasons, 1) {
+			return store.Cart{}, fmt.Errorf("cart ID collision, try again: %w", err)
+		}
+		return store.Cart{}, err
+	}
+
+	return store.Cart{
+		CartID:     cartID,
+		CustomerID: customerID,
+		Status:     store.CartStatusActive,
+		Version:    1,
+		Items:      []store.CartItem{},
+	}, nil
+}
+
+/*
+Get
+Get a shopping cart with its items (one Query on the cart partition).
+*/
+func (s *Store) Get(ctx context.Context, cartID string) (store.Cart, error) {
+	return s.readCart(ctx, cartID, false)
+}
+
+/* Internal function: read a shopping cart with its items, strongly consistent if consistent is true
+ * (needed right after a write whose result is returned, an eventually consistent read may miss it) */
+func (s *Store) readCart(ctx context.Context, cartID string, consistent bool) (store.Cart, error) {
+	cartPK, err := cartKey(cartID)
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	output, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: cartPK},
+		},
+		ConsistentRead: aws.Bool(consistent),
+	})
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	// This is how we check for "Not Found" in DynamoDB
+	if len(output.Items) == 0 {
+		return store.Cart{}, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartID)
+	}
+
+	cart := store.Cart{Items: []store.CartItem{}}
+	foundCartMeta := false
+
+	// Loop through all rows (cart metadata + item data) returned by the query
+	for _, dbItem := range output.Items {
+		skValue, ok := dbItem["SK"].(*types.AttributeValueMemberS)
+		if !ok {
+			continue // Skip item if SK is not a string
+		}
+
+		if skValue.Value == "CART" {
+			// This is the main cart metadata row
+			var meta cartMetadata
+			if err := attributevalue.UnmarshalMap(dbItem, &meta); err != nil {
+				return store.Cart{}, err
+			}
+			cart.CartID = meta.CartID
+			cart.CustomerID = meta.CustomerID
+			cart.Status = meta.Status
+			cart.Version = meta.Version
+			cart.Currency = meta.Currency
+			foundCartMeta = true
+		} else if strings.HasPrefix(skValue.Value, "ITEM#") {
+			// This is an item row
+			var item cartItemData
+			if err := attributevalue.UnmarshalMap(dbItem, &item); err != nil {
+				return store.Cart{}, err
+			}
+			cart.Items = append(cart.Items, store.CartItem{
+				ProductID:   item.ProductID,
+				ProductName: item.ProductName,
+				Quantity:    item.Quantity,
+				UnitPrice:   item.UnitPrice,
+			})
+		}
+	}
+
+	if !foundCartMeta {
+		return store.Cart{}, fmt.Errorf("%w: cart data corrupted, cart %s has items but no main record", store.ErrCartNotFound, cartID)
+	}
+	cart.SetTotals()
+	return cart, nil
+}
+
+// GSI over GSI1PK = CUST#<customer_id> / GSI1SK = <created_at>#<uuid> (see terraform modules/dynamoDB)
+const customerIndex = "GSI1-CustomerIndex"
+
+/*
+ListCarts
+List the carts of a customer with a Query on GSI1-CustomerIndex, newest first (descending GSI1SK).
+The next cursor is the encoded LastEvaluatedKey; the status filter is applied by DynamoDB after reading,
+so the query is repeated until the page is full or the index partition is exhausted.
+*/
+func (s *Store) ListCarts(ctx context.Context, query store.CartListQuery) (store.CartPage, error) {
+	custPK := fmt.Sprintf("CUST#%d", query.CustomerID)
+
+	var startKey map[string]types.AttributeValue
+	if query.Cursor != "" {
+		var position map[string]string
+		if err := store.DecodeCursor(query.Cursor, &position); err != nil {
+			return store.CartPage{}, err
+		}
+		if !validCartCursor(position, custPK) { // tampered cursor, or cursor of another customer or another listing
+			return store.CartPage{}, fmt.Errorf("%w: %s", store.ErrInvalidCursor, query.Cursor)
+		}
+		startKey = make(map[string]types.AttributeValue, len(position))
+		for name, value := range position {
+			startKey[name] = &types.AttributeValueMemberS{Value: value}
+		}
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		IndexName:              aws.String(customerIndex),
+		ScanIndexForward:       aws.Bool(false),
+		KeyConditionExpression: aws.String("GSI1PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: custPK},
+		},
+	}
+	if query.Status != "" {
+		input.FilterExpression = aws.String("#status = :status")
+		input.ExpressionAttributeNames = map[string]string{"#status": "status"}
+		input.ExpressionAttributeValues[":status"] = &types.AttributeValueMemberS{Value: query.Status}
+	}
+
+	page := store.CartPage{Carts: make([]store.CartSummary, 0, query.Limit)}
+	for {
+		// never read more items than the page can hold, so LastEvaluatedKey is the last returned cart
+		input.Limit = aws.Int32(int32(query.Limit - len(page.Carts)))
+		input.ExclusiveStartKey = startKey
+		output, err := s.client.Query(ctx, input)
+		if err != nil {
+			return store.CartPage{}, err
+		}
+		for _, dbItem := range output.Items {
+			var meta cartMetadata
+			if err := attributevalue.UnmarshalMap(dbItem, &meta); err != nil {
+				return store.CartPage{}, err
+			}
+			page.Carts = append(page.Carts, store.CartSummary{
+				CartID:     meta.CartID,
+				CustomerID: meta.CustomerID,
+				Status:     meta.Status,
+			})
+		}
+
+		startKey = output.LastEvaluatedKey
+		if len(startKey) == 0 || len(page.Carts) == query.Limit {
+			break
+		}
+	}
+
+	if len(startKey) > 0 {
+		position := make(map[string]string, len(startKey))
+		for name, value := range startKey {
+			if v, ok := value.(*types.AttributeValueMemberS); ok {
+				position[name] = v.Value
+			}
+		}
+		cursor, err := store.EncodeCursor(position)
+		if err != nil {
+			return store.CartPage{}, err
+		}
+		page.NextCursor = cursor
+	}
+	return page, nil
+}
+
+/* Internal function: check that a decoded cursor is the LastEvaluatedKey of a cart of the customer in GSI1
+ * (exactly PK, SK, GSI1PK and GSI1SK, all consistent with each other)
+ * Carts created before GSI1SK held the creation time have GSI1SK = PK (they are listed first). */
+func validCartCursor(position map[string]string, custPK string) bool {
+	if len(position) != 4 || position["GSI1PK"] != custPK || position["SK"] != "CART" {
+		return false
+	}
+	cartID, ok := strings.CutPrefix(position["PK"], "CART#")
+	if !ok || uuid.Validate(cartID) != nil {
+		return false
+	}
+	if position["GSI1SK"] == position["PK"] {
+		return true
+	}
+	createdAt, ok := strings.CutSuffix(position["GSI1SK"], "#"+cartID)
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(createdAtLayout, createdAt)
+	return err == nil
+}
+
+// TransactWriteItems accepts 100 actions, one of them is the version update of the CART row
+const maxItemsPerUpdate = 99
+
+// attempts of a cart update when the cart changed between reading its version and writing
+const upsertAttempts = 3
+
+/*
+UpsertItems
+Add or update items in existing cart.
+This is the NoSQL equivalent of "INSERT...ON DUPLICATE KEY UPDATE": a Put creates or overwrites the ITEM# row,
+and a Delete removes the row of an item with quantity 0.
+Every product must exist in the catalog (PRODUCT_NOT_FOUND otherwise), its name is copied into the ITEM# row.
+All writes are one transaction with a conditional version increment of the CART row (the MySQL SELECT ... FOR UPDATE):
+nothing is written if the cart does not exist (CART_NOT_FOUND), is not active (CART_NOT_ACTIVE)
+or changed since its version was read (retried, or ErrVersionMismatch when the caller expects ifVersion).
+Once the attempts are used up, a cart that kept changing fails with ErrVersionMismatch
+and a cart that stayed locked by other transactions with ErrConcurrentUpdate.
+*/
+func (s *Store) UpsertItems(ctx context.Context, cartID string, items []store.ItemUpdate, ifVersion uint64) (uint64, error) {
+	cartPK, err := cartKey(cartID)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) > maxItemsPerUpdate {
+		return 0, fmt.Errorf("%w: %d items in one update (max: %d)", store.ErrTooManyItems, len(items), maxItemsPerUpdate)
+	}
+
+	// check if all products exist before touching the cart
+	productIDs := make([]int32, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := s.productSummaries(ctx, productIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	itemWrites := make([]types.TransactWriteItem, 0, len(items))
+	for _, item := range items {
+		itemKey := map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: cartPK},
+			"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("ITEM#%d", item.ProductID)},
+		}
+		if item.Quantity == 0 {
+			itemWrites = append(itemWrites, types.TransactWriteItem{
+				Delete: &types.Delete{TableName: aws.String(s.tableName), Key: itemKey},
+			})
+			continue
+		}
+
+		// an existing item keeps the unit price it was added at
+		itemWrites = append(itemWrites, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName:                aws.String(s.tableName),
+				Key:                      itemKey,
+				UpdateExpression:         aws.String("SET product_id = :pid, #quantity = :quantity, product_name = :name, unit_price = if_not_exists(unit_price, :price), GSI2PK = :product, GSI2SK = :cart"),
+				ExpressionAttributeNames: map[string]string{"#quantity": "quantity"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":product":  &types.AttributeValueMemberS{Value: fmt.Sprintf("PRODUCT#%d", item.ProductID)},
+					":cart":     &types.AttributeValueMemberS{Value: cartPK},
+					":pid":      &types.AttributeValueMemberN{Value: strconv.Itoa(int(item.ProductID))},
+					":quantity": &types.AttributeValueMemberN{Value: strconv.FormatUint(uint64(item.Quantity), 10)},
+					":name":     &types.AttributeValueMemberS{Value: products[item.ProductID].Name},
+					":price":    &types.AttributeValueMemberN{Value: strconv.FormatInt(products[item.ProductID].Price, 10)},
+				},
+			},
+		})
+	}
+
+	// the cart takes the currency of its first product, checked against every product added or updated
+	// (the items are not read, so an item whose product changed currency since it was added is rejected too)
+	added := make(map[int32]string)
+	for _, item := range items {
+		if item.Quantity > 0 {
+			added[item.ProductID] = products[item.ProductID].Currency
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		// read the current status and version of the cart
+		meta, err := s.cartMetadata(ctx, cartPK, cartID)
+		if err != nil {
+			return 0, err
+		}
+		if ifVersion != 0 && meta.Version != ifVersion {
+			return 0, fmt.Errorf("%w: shopping cart %s is at version %d (expected: %d)", store.ErrVersionMismatch, cartID, meta.Version, ifVersion)
+		}
+		if meta.Status != store.CartStatusActive {
+			return 0, fmt.Errorf("%w: shopping cart %s is '%s'", store.ErrCartNotActive, cartID, meta.Status)
+		}
+		currency, err := store.CartCurrency(cartID, meta.Currency, added)
+		if err != nil {
+			return 0, err
+		}
+		update := "ADD version :one"
+		values := map[string]types.AttributeValue{
+			":one":     &types.AttributeValueMemberN{Value: "1"},
+			":active":  &types.AttributeValueMemberS{Value: store.CartStatusActive},
+			":version": &types.AttributeValueMemberN{Value: strconv.FormatUint(meta.Version, 10)},
+		}
+		if currency != "" {
+			update = "SET currency = :currency " + update
+			values[":currency"] = &types.AttributeValueMemberS{Value: currency}
+		}
+
+		versionUpdate := types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(s.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: cartPK},
+					"SK": &types.AttributeValueMemberS{Value: "CART"},
+				},
+				UpdateExpression:          aws.String(update),
+				ConditionExpression:       aws.String("#status = :active AND version = :version"),
+				ExpressionAttributeNames:  map[string]string{"#status": "status"},
+				ExpressionAttributeValues: values,
+			},
+		}
+		_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: append([]types.TransactWriteItem{versionUpdate}, itemWrites...),
+		})
+		if err == nil {
+			return meta.Version + 1, nil
+		}
+
+		// the cart changed since it was read, or another transaction was writing it: read it again
+		var cancelled *types.TransactionCanceledException
+		if !errors.As(err, &cancelled) {
+			return 0, err
+		}
+		if err := upsertCancelled(cartID, cancelled.CancellationReasons, attempt); err != nil {
+			return 0, err
+		}
+		if err := sleepBackoff(ctx, attempt); err != nil {
+			return 0, err
+		}
+	}
+}
+
+/* Internal function: decide what happens after attempt of an item update was cancelled, nil means retry
+ * The version condition of the CART row (first action) failing for the last time becomes ErrVersionMismatch,
+ * a cart still locked by other transactions ErrConcurrentUpdate. */
+func upsertCancelled(cartID string, reasons []types.CancellationReason, attempt int) error {
+	versionChanged := conditionFailed(reasons, 0)
+	locked := transactionConflict(reasons)
+	switch {
+	case !versionChanged && !locked:
+		return fmt.Errorf("shopping cart %s update was cancelled (%s)", cartID, cancellationCodes(reasons))
+	case attempt < upsertAttempts:
+		return nil
+	case versionChanged:
+		return fmt.Errorf("%w: shopping cart %s changed during %d attempts to update it", store.ErrVersionMismatch, cartID, upsertAttempts)
+	default:
+		return fmt.Errorf("%w: shopping cart %s was locked by other transactions during %d attempts to update it", store.ErrConcurrentUpdate, cartID, upsertAttempts)
+	}
+}
+
+/* Internal function: strongly consistent read of the CART row */
+func (s *Store) cartMetadata(ctx context.Context, cartPK string, cartID string) (cartMetadata, error) {
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: cartPK},
+			"SK": &types.AttributeValueMemberS{Value: "CART"},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return cartMetadata{}, err
+	}
+	if len(output.Item) == 0 {
+		return cartMetadata{}, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartID)
+	}
+
+	var meta cartMetadata
+	if err := attributevalue.UnmarshalMap(output.Item, &meta); err != nil {
+		return cartMetadata{}, err
+	}
+	return meta, nil
+}
+
+/* Internal function: check if a transaction was cancelled because another transaction was using one of its items */
+func transactionConflict(reasons []types.CancellationReason) bool {
+	for _, reason := range reasons {
+		if aws.ToString(reason.Code) == "TransactionConflict" {
+			return true
+		}
+	}
+	return false
+}
+
+/* Internal function: cancellation codes of a transaction (e.g. "None, ConditionalCheckFailed") for error messages */
+func cancellationCodes(reasons []types.CancellationReason) string {
+	codes := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		codes = append(codes, aws.ToString(reason.Code))
+	}
+	return strings.Join(codes, ", ")
+}
+
+// attempts to move an active cart before giving up when its status keeps changing concurrently
+const transitionAttempts = 3
+
+/*
+Transition
+Move a cart to another status with a conditional UpdateItem on the CART row:
+the update only succeeds if the current status is one of store.TransitionSources(to).
+A cart leaving active also deletes the customer's ACTIVE_CART guard in the same transaction,
+so that the customer can create a new cart. A cancelled transaction never surfaces as a DynamoDB error,
+see transitionCancelled.
+*/
+func (s *Store) Transition(ctx context.Context, cartID string, to string) (store.Cart, error) {
+	cartPK, err := cartKey(cartID)
+	if err != nil {
+		return store.Cart{}, err
+	}
+	if len(store.TransitionSources(to)) == 0 {
+		return store.Cart{}, fmt.Errorf("unknown shopping cart status '%s'", to)
+	}
+
+	// only ordered and cancelled can be reached from active
+	if !store.CanTransition(store.CartStatusActive, to) {
+		return s.updateStatus(ctx, cartPK, cartID, to)
+	}
+
+	for attempt := 1; ; attempt++ {
+		cart, err := s.readCart(ctx, cartID, true)
+		if err != nil {
+			return store.Cart{}, err
+		}
+		if cart.Status != store.CartStatusActive {
+			if !store.CanTransition(cart.Status, to) {
+				return store.Cart{}, &store.InvalidTransitionError{CartID: cartID, From: cart.Status, To: to}
+			}
+			return s.updateStatus(ctx, cartPK, cartID, to)
+		}
+		// checkout requires at least one item
+		if to == store.CartStatusOrdered && len(cart.Items) == 0 {
+			return store.Cart{}, fmt.Errorf("%w: shopping cart %s can not be checked out", store.ErrCartEmpty, cartID)
+		}
+
+		err = s.leaveActive(ctx, cartPK, cart, to)
+		var cancelled *types.TransactionCanceledException
+		if errors.As(err, &cancelled) {
+			retry := conditionFailed(cancelled.CancellationReasons, 0) || transactionConflict(cancelled.CancellationReasons)
+			if retry && attempt < transitionAttempts {
+				continue // the cart changed or left active meanwhile, read it again
+			}
+			return s.transitionCancelled(ctx, cartPK, cartID, to, cancelled.CancellationReasons)
+		}
+		if err != nil {
+			return store.Cart{}, err
+		}
+
+		cart.Status = to
+		cart.Version++
+		return cart, nil
+	}
+}
+
+/* Internal function: outcome of a transition whose last leaveActive transaction was cancelled
+ * If the status condition of the CART row failed, the cart is read again: a cart that left active meanwhile
+ * is moved by updateStatus or fails with InvalidTransitionError, a cart still active kept changing (ErrConcurrentUpdate).
+ * Transactions cancelled by other transactions fail with ErrConcurrentUpdate. */
+func (s *Store) transitionCancelled(ctx context.Context, cartPK string, cartID string, to string, reasons []types.CancellationReason) (store.Cart, error) {
+	switch {
+	case conditionFailed(reasons, 0):
+		cart, err := s.readCart(ctx, cartID, true)
+		if err != nil {
+			return store.Cart{}, err
+		}
+		if cart.Status == store.CartStatusActive {
+			return store.Cart{}, fmt.Errorf("%w: shopping cart %s changed during %d attempts to make it '%s'", store.ErrConcurrentUpdate, cartID, transitionAttempts, to)
+		}
+		if !store.CanTransition(cart.Status, to) {
+			return store.Cart{}, &store.InvalidTransitionError{CartID: cartID, From: cart.Status, To: to}
+		}
+		return s.updateStatus(ctx, cartPK, cartID, to)
+	case transactionConflict(reasons):
+		return store.Cart{}, fmt.Errorf("%w: shopping cart %s was locked by other transactions during %d attempts to make it '%s'", store.ErrConcurrentUpdate, cartID, transitionAttempts, to)
+	case conditionFailed(reasons, 1):
+		return store.Cart{}, fmt.Errorf("%w: the active cart of the customer is not shopping cart %s", store.ErrConcurrentUpdate, cartID)
+	default:
+		return store.Cart{}, fmt.Errorf("shopping cart %s status update was cancelled (%s)", cartID, cancellationCodes(reasons))
+	}
+}
+
+/* Internal function: set the status of an active cart (still at cart.Version) and delete the customer's ACTIVE_CART guard (if it still points to this cart) */
+func (s *Store) leaveActive(ctx context.Context, cartPK string, cart store.Cart, to string) error {
+	_, err := s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Update: &types.Update{
+				TableName: aws.String(s.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: cartPK},
+					"SK": &types.AttributeValueMemberS{Value: "CART"},
+				},
+				UpdateExpression:         aws.String("SET #status = :to ADD version :one"),
+				ConditionExpression:      aws.String("#status = :active AND version = :version"),
+				ExpressionAttributeNames: map[string]string{"#status": "status"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":to":      &types.AttributeValueMemberS{Value: to},
+					":one":     &types.AttributeValueMemberN{Value: "1"},
+					":active":  &types.AttributeValueMemberS{Value: store.CartStatusActive},
+					":version": &types.AttributeValueMemberN{Value: strconv.FormatUint(cart.Version, 10)},
+				},
+			}},
+			{Delete: &types.Delete{
+				TableName:           aws.String(s.tableName),
+				Key:                 activeCartKey(cart.CustomerID),
+				ConditionExpression: aws.String("attribute_not_exists(PK) OR cart_id = :cart_id"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":cart_id": &types.AttributeValueMemberS{Value: cart.CartID},
+				},
+			}},
+		},
+	})
+	return err
+}
+
+/* Internal function: conditional status update of a cart that is not active (no guard to release) */
+func (s *Store) updateStatus(ctx context.Context, cartPK string, cartID string, to string) (store.Cart, error) {
+	// build "#status IN (:from0, :from1, ...)", active is left to leaveActive
+	values := map[string]types.AttributeValue{
+		":to":  &types.AttributeValueMemberS{Value: to},
+		":one": &types.AttributeValueMemberN{Value: "1"},
+	}
+	placeholders := make([]string, 0)
+	for i, from := range store.TransitionSources(to) {
+		if from == store.CartStatusActive {
+			continue
+		}
+		key := fmt.Sprintf(":from%d", i)
+		values[key] = &types.AttributeValueMemberS{Value: from}
+		placeholders = append(placeholders, key)
+	}
+	if len(placeholders) == 0 {
+		return store.Cart{}, fmt.Errorf("shopping cart %s can only become '%s' from active", cartID, to)
+	}
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: cartPK},
+			"SK": &types.AttributeValueMemberS{Value: "CART"},
+		},
+		UpdateExpression:                    aws.String("SET #status = :to ADD version :one"),
+		ConditionExpression:                 aws.String(fmt.Sprintf("attribute_exists(PK) AND #status IN (%s)", strings.Join(placeholders, ", "))),
+		ExpressionAttributeNames:            map[string]string{"#status": "status"},
+		ExpressionAttributeValues:           values,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var condFailed *types.ConditionalCheckFailedException
+		if !errors.As(err, &condFailed) {
+			return store.Cart{}, err
+		}
+		// no old item: the cart does not exist, otherwise its status does not allow the transition
+		if len(condFailed.Item) == 0 {
+			return store.Cart{}, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartID)
+		}
+		var meta cartMetadata
+		if err := attributevalue.UnmarshalMap(condFailed.Item, &meta); err != nil {
+			return store.Cart{}, err
+		}
+		return store.Cart{}, &store.InvalidTransitionError{CartID: cartID, From: meta.Status, To: to}
+	}
+
+	// the response and its ETag must show the new status and version
+	return s.readCart(ctx, cartID, true)
+}
+
+// compile-time check
+var _ store.CartStore = (*Store)(nil)
+var _ store.CartLister = (*Store)(nil)
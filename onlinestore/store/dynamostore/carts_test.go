@@ -0,0 +1,196 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"hw8-onlinestore/store"
+)
+
+func TestValidCartCursor(t *testing.T) {
+	const cartID = "0b0e7c1e-5a55-4f5e-9a57-3c7c2a7c1f00"
+	createdAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	valid := func() map[string]string {
+		return map[string]string{
+			"PK":     "CART#" + cartID,
+			"SK":     "CART",
+			"GSI1PK": "CUST#42",
+			"GSI1SK": customerCartSortKey(createdAt, cartID),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(map[string]string)
+		want   bool
+	}{
+		{"valid", func(map[string]string) {}, true},
+		{"cart created before created_at sort keys", func(p map[string]string) { p["GSI1SK"] = p["PK"] }, true},
+		{"other customer", func(p map[string]string) { p["GSI1PK"] = "CUST#43" }, false},
+		{"missing key", func(p map[string]string) { delete(p, "SK") }, false},
+		{"extra key", func(p map[string]string) { p["status"] = "active" }, false},
+		{"item row", func(p map[string]string) { p["SK"] = "ITEM#1" }, false},
+		{"invalid cart ID", func(p map[string]string) { p["PK"] = "CART#x" }, false},
+		{"sort key of another cart", func(p map[string]string) {
+			p["GSI1SK"] = customerCartSortKey(createdAt, "5d1f3c2a-0000-4000-8000-000000000000")
+		}, false},
+		{"invalid creation time", func(p map[string]string) { p["GSI1SK"] = "yesterday#" + cartID }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position := valid()
+			tt.change(position)
+			if got := validCartCursor(position, "CUST#42"); got != tt.want {
+				t.Fatalf("validCartCursor(%v) = %v, want %v", position, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomerCartSortKeyOrder(t *testing.T) {
+	first := time.Date(2026, 10, 16, 12, 0, 0, 900000000, time.UTC)
+	second := first.Add(100 * time.Millisecond) // crosses a second boundary
+	if a, b := customerCartSortKey(first, "b"), customerCartSortKey(second, "a"); a >= b {
+		t.Fatalf("expected %s to sort before %s", a, b)
+	}
+}
+
+/* Internal function: cancellation reasons with the given codes */
+func reasons(codes ...string) []types.CancellationReason {
+	out := make([]types.CancellationReason, 0, len(codes))
+	for _, code := range codes {
+		out = append(out, types.CancellationReason{Code: aws.String(code)})
+	}
+	return out
+}
+
+func TestUpsertCancelled(t *testing.T) {
+	tests := []struct {
+		name    string
+		reasons []types.CancellationReason
+		attempt int
+		want    error // nil means retry
+	}{
+		{"version changed, retry", reasons("ConditionalCheckFailed", "None"), 1, nil},
+		{"locked, retry", reasons("None", "TransactionConflict"), upsertAttempts - 1, nil},
+		{"version kept changing", reasons("ConditionalCheckFailed", "None"), upsertAttempts, store.ErrVersionMismatch},
+		{"still locked", reasons("TransactionConflict", "None"), upsertAttempts, store.ErrConcurrentUpdate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := upsertCancelled("cart", tt.reasons, tt.attempt)
+			if (tt.want == nil && err != nil) || !errors.Is(err, tt.want) {
+				t.Fatalf("upsertCancelled() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// other cancellations are neither retried nor mapped to a client error
+	err := upsertCancelled("cart", reasons("None", "ValidationError"), 1)
+	if err == nil || errors.Is(err, store.ErrVersionMismatch) || errors.Is(err, store.ErrConcurrentUpdate) {
+		t.Fatalf("expected a server error, got %v", err)
+	}
+}
+
+const testCartID = "0b0e7c1e-5a55-4f5e-9a57-3c7c2a7c1f00"
+
+/* Internal function: CART row of the test cart */
+func testCartRow(t *testing.T, status string, version uint64) map[string]types.AttributeValue {
+	t.Helper()
+
+	row, err := attributevalue.MarshalMap(cartMetadata{
+		PK: "CART#" + testCartID, SK: "CART", CartID: testCartID, CustomerID: 42, Status: status, Version: version,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal cart: %v", err)
+	}
+	return row
+}
+
+/* Internal function: fake client of a cart update, answering every product and counting the transaction actions */
+func upsertClient(t *testing.T, actions *int) *fakeClient {
+	return &fakeClient{
+		batchGetItem: func(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+			found := make([]map[string]types.AttributeValue, 0)
+			for _, key := range input.RequestItems["test"].Keys {
+				var product productData
+				if err := attributevalue.UnmarshalMap(key, &product); err != nil {
+					t.Fatalf("failed to unmarshal key: %v", err)
+				}
+				var id int32
+				if _, err := fmt.Sscanf(product.PK, "PRODUCT#%d", &id); err != nil {
+					t.Fatalf("unexpected product key %s", product.PK)
+				}
+				item, _ := attributevalue.MarshalMap(productData{ProductID: id, Name: "Product", Price: 100, Currency: store.DefaultCurrency})
+				found = append(found, item)
+			}
+			return &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{"test": found}}, nil
+		},
+		getItem: func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: testCartRow(t, store.CartStatusActive, 1)}, nil
+		},
+		transactWriteItems: func(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			*actions = len(input.TransactItems)
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+	}
+}
+
+/* Internal function: item updates of products 1..n */
+func itemUpdates(n int) []store.ItemUpdate {
+	items := make([]store.ItemUpdate, 0, n)
+	for i := 1; i <= n; i++ {
+		items = append(items, store.ItemUpdate{ProductID: int32(i), Quantity: 1})
+	}
+	return items
+}
+
+func TestUpsertItemsLimit(t *testing.T) {
+	// the largest update fills a transaction: the CART version update and one action per item
+	actions := 0
+	s := newTestStore(upsertClient(t, &actions))
+	version, err := s.UpsertItems(context.Background(), testCartID, itemUpdates(maxItemsPerUpdate), 0)
+	if err != nil {
+		t.Fatalf("failed to update %d items: %v", maxItemsPerUpdate, err)
+	}
+	if version != 2 || actions != 100 {
+		t.Fatalf("expected version 2 after a transaction of 100 actions, got version %d after %d actions", version, actions)
+	}
+
+	// one more item is rejected before any call
+	s = newTestStore(&fakeClient{})
+	if _, err := s.UpsertItems(context.Background(), testCartID, itemUpdates(maxItemsPerUpdate+1), 0); !errors.Is(err, store.ErrTooManyItems) {
+		t.Fatalf("expected ErrTooManyItems for %d items, got %v", maxItemsPerUpdate+1, err)
+	}
+}
+
+func TestTransitionReturnsWrittenStatus(t *testing.T) {
+	// an eventually consistent read still sees the cart before the update
+	s := newTestStore(&fakeClient{
+		updateItem: func(*dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			if aws.ToBool(input.ConsistentRead) {
+				return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{testCartRow(t, store.CartStatusShipped, 4)}}, nil
+			}
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{testCartRow(t, store.CartStatusPaid, 3)}}, nil
+		},
+	})
+
+	cart, err := s.Transition(context.Background(), testCartID, store.CartStatusShipped)
+	if err != nil {
+		t.Fatalf("failed to ship the cart: %v", err)
+	}
+	if cart.Status != store.CartStatusShipped || cart.Version != 4 {
+		t.Fatalf("expected the shipped cart at version 4, got '%s' at version %d", cart.Status, cart.Version)
+	}
+}
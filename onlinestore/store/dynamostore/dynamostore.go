@@ -0,0 +1,96 @@
+package dynamostore
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// dynamoClient is the part of the DynamoDB API used by the store (*dynamodb.Client, fakes in tests)
+type dynamoClient interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+/* Store implements store.CartStore and store.ProductStore on top of a single DynamoDB table
+ * Table layout:
+ *   CART#<uuid> / CART              cart metadata (GSI1PK = CUST#<customer_id>, GSI1SK = <created_at>#<uuid>)
+ *   CART#<uuid> / ITEM#<product_id> one row per line item (GSI2PK = PRODUCT#<product_id>, GSI2SK = CART#<uuid>)
+ *   CUST#<customer_id> / ACTIVE_CART the customer's active cart_id (uniqueness guard, deleted when the cart leaves active)
+ *   PRODUCT#<product_id> / PRODUCT  product catalog (GSI3PK = PRODUCT, GSI3SK = <product_id>, read by Search)
+ *   SEQUENCE#PRODUCT / SEQUENCE      last product_id assigned to a new product
+ *   IDEMPOTENCY#<key> / IDEMPOTENCY  stored response of an Idempotency-Key (expires_at is the table TTL) */
+type Store struct {
+	client    dynamoClient
+	tableName string
+}
+
+/* New returns a Store that reads and writes tableName through client */
+func New(client *dynamodb.Client, tableName string) *Store {
+	return &Store{client: client, tableName: tableName}
+}
+
+// ---
+// DynamoDB Internal Structs (for mapping)
+// ---
+type cartMetadata struct {
+	PK         string `dynamodbav:"PK"`
+	SK         string `dynamodbav:"SK"`
+	GSI1PK     string `dynamodbav:"GSI1PK"`
+	GSI1SK     string `dynamodbav:"GSI1SK"`
+	CartID     string `dynamodbav:"cart_id"`
+	CustomerID uint64 `dynamodbav:"customer_id"`
+	Status     string `dynamodbav:"status"`
+	Version    uint64 `dynamodbav:"version"`
+	Currency   string `dynamodbav:"currency,omitempty"` // set by the first item added
+}
+type cartItemData struct {
+	PK          string `dynamodbav:"PK"`
+	SK          string `dynamodbav:"SK"`
+	GSI2PK      string `dynamodbav:"GSI2PK"`
+	GSI2SK      string `dynamodbav:"GSI2SK"`
+	ProductID   int32  `dynamodbav:"product_id"`
+	Quantity    uint   `dynamodbav:"quantity"`
+	ProductName string `dynamodbav:"product_name"`
+	UnitPrice   int64  `dynamodbav:"unit_price"` // product price when the item was added
+}
+type activeCartGuard struct {
+	PK         string `dynamodbav:"PK"`
+	SK         string `dynamodbav:"SK"`
+	CartID     string `dynamodbav:"cart_id"`
+	CustomerID uint64 `dynamodbav:"customer_id"`
+}
+type productData struct {
+	PK            string `dynamodbav:"PK"`
+	SK            string `dynamodbav:"SK"`
+	GSI3PK        string `dynamodbav:"GSI3PK"`
+	GSI3SK        int32  `dynamodbav:"GSI3SK"`
+	ProductID     int32  `dynamodbav:"product_id"`
+	Name          string `dynamodbav:"name"`
+	Category      string `dynamodbav:"category"`
+	Brand         string `dynamodbav:"brand"`
+	Description   string `dynamodbav:"description"`
+	NameLower     string `dynamodbav:"name_lowercase"`
+	CategoryLower string `dynamodbav:"category_lowercase"`
+	Price         int64  `dynamodbav:"price"`
+	Currency      string `dynamodbav:"currency"`
+}
+type productSequence struct {
+	LastID int32 `dynamodbav:"last_id"`
+}
+type idempotencyData struct {
+	PK          string            `dynamodbav:"PK"`
+	SK          string            `dynamodbav:"SK"`
+	Fingerprint string            `dynamodbav:"fingerprint"`
+	StatusCode  int               `dynamodbav:"status_code"`
+	Headers     map[string]string `dynamodbav:"headers,omitempty"`
+	Body        []byte            `dynamodbav:"body,omitempty"`
+	ExpiresAt   int64             `dynamodbav:"expires_at"` // unix seconds
+}
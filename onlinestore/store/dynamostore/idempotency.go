@@ -0,0 +1,128 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"hw8-onlinestore/store"
+)
+
+/* Internal function: primary key of an idempotency item */
+func idempotencyKey(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "IDEMPOTENCY#" + key},
+		"SK": &types.AttributeValueMemberS{Value: "IDEMPOTENCY"},
+	}
+}
+
+/*
+ClaimIdempotencyKey
+Claim an Idempotency-Key with a conditional PutItem that only succeeds for a new or expired key,
+otherwise the stored item is returned by ReturnValuesOnConditionCheckFailure.
+expires_at is the table's TTL attribute, DynamoDB deletes expired items in the background.
+*/
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key string, fingerprint string, lease time.Duration) (*store.IdempotentResponse, error) {
+	now := time.Now()
+	dbItem, err := attributevalue.MarshalMap(idempotencyData{
+		PK:          "IDEMPOTENCY#" + key,
+		SK:          "IDEMPOTENCY",
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(lease).Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency key: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.tableName),
+		Item:                dbItem,
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR expires_at <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err == nil {
+		return nil, nil // claimed
+	}
+
+	var condFailed *types.ConditionalCheckFailedException
+	if !errors.As(err, &condFailed) {
+		return nil, err
+	}
+	var existing idempotencyData
+	if err := attributevalue.UnmarshalMap(condFailed.Item, &existing); err != nil {
+		return nil, err
+	}
+	return &store.IdempotentResponse{
+		Fingerprint: existing.Fingerprint,
+		StatusCode:  existing.StatusCode,
+		Headers:     existing.Headers,
+		Body:        existing.Body,
+	}, nil
+}
+
+/*
+SaveIdempotentResponse
+Store the response of the request that claimed key (the fingerprint must still match).
+*/
+func (s *Store) SaveIdempotentResponse(ctx context.Context, key string, resp store.IdempotentResponse, ttl time.Duration) error {
+	headers, err := attributevalue.Marshal(resp.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal headers: %w", err)
+	}
+	body := resp.Body
+	if body == nil {
+		body = []byte{}
+	}
+
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.tableName),
+		Key:                 idempotencyKey(key),
+		UpdateExpression:    aws.String("SET status_code = :status, headers = :headers, body = :body, expires_at = :expires"),
+		ConditionExpression: aws.String("fingerprint = :fingerprint"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status":      &types.AttributeValueMemberN{Value: strconv.Itoa(resp.StatusCode)},
+			":headers":     headers,
+			":body":        &types.AttributeValueMemberB{Value: body},
+			":expires":     &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)},
+			":fingerprint": &types.AttributeValueMemberS{Value: resp.Fingerprint},
+		},
+	})
+	var condFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &condFailed) {
+		return nil // the key expired and was claimed by another request meanwhile
+	}
+	return err
+}
+
+/*
+ReleaseIdempotencyKey
+Forget a key that is still in progress.
+*/
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(s.tableName),
+		Key:                 idempotencyKey(key),
+		ConditionExpression: aws.String("status_code = :zero"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+		},
+	})
+	var condFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &condFailed) {
+		return nil // already completed or gone
+	}
+	return err
+}
+
+// compile-time check
+var _ store.IdempotencyStore = (*Store)(nil)
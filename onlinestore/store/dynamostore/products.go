@@ -0,0 +1,554 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"hw8-onlinestore/store"
+)
+
+// DynamoDB API limits
+const (
+	batchGetLimit   = 100 // keys per BatchGetItem call
+	batchWriteLimit = 25  // requests per BatchWriteItem call
+)
+
+/* Internal function: primary key of a product item */
+func productKey(productID int32) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("PRODUCT#%d", productID)},
+		"SK": &types.AttributeValueMemberS{Value: "PRODUCT"},
+	}
+}
+
+/* Internal function: convert a product to its table item */
+func toProductData(p store.Product) productData {
+	return productData{
+		PK:            fmt.Sprintf("PRODUCT#%d", p.ID),
+		SK:            "PRODUCT",
+		GSI3PK:        catalogPartition,
+		GSI3SK:        p.ID,
+		ProductID:     p.ID,
+		Name:          p.Name,
+		Category:      p.Category,
+		Brand:         p.Brand,
+		Description:   p.Description,
+		NameLower:     strings.ToLower(p.Name),
+		CategoryLower: strings.ToLower(p.Category),
+		Price:         p.Price,
+		Currency:      p.Currency,
+	}
+}
+
+/* Internal function: convert a table item to a product */
+func (d productData) toProduct() store.Product {
+	return store.Product{
+		ID:            d.ProductID,
+		Name:          d.Name,
+		Category:      d.Category,
+		Brand:         d.Brand,
+		Description:   d.Description,
+		NameLower:     d.NameLower,
+		CategoryLower: d.CategoryLower,
+		Price:         d.Price,
+		Currency:      d.Currency,
+	}
+}
+
+/* Query Product in the table by product_id */
+func (s *Store) GetProduct(ctx context.Context, productID int32) (store.Product, error) {
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key:       productKey(productID),
+	})
+	if err != nil {
+		return store.Product{}, err
+	}
+	if len(output.Item) == 0 {
+		return store.Product{}, fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, productID)
+	}
+
+	var item productData
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return store.Product{}, err
+	}
+	return item.toProduct(), nil
+}
+
+/* Update product information if the product exists (conditional UpdateItem)
+ * price and currency are only in the update expression when the update sets them, so omitted ones keep their stored values */
+func (s *Store) UpdateProduct(ctx context.Context, u store.ProductUpdate) error {
+	// the GSI3 keys are set as well, for products written before GSI3-CatalogIndex existed
+	updateExpr := "SET #name = :name, category = :category, brand = :brand, #description = :description, " +
+		"name_lowercase = :name_lower, category_lowercase = :category_lower, GSI3PK = :catalog, GSI3SK = :pid"
+	values := map[string]types.AttributeValue{
+		":catalog":        &types.AttributeValueMemberS{Value: catalogPartition},
+		":pid":            &types.AttributeValueMemberN{Value: strconv.Itoa(int(u.ID))},
+		":name":           &types.AttributeValueMemberS{Value: u.Name},
+		":category":       &types.AttributeValueMemberS{Value: u.Category},
+		":brand":          &types.AttributeValueMemberS{Value: u.Brand},
+		":description":    &types.AttributeValueMemberS{Value: u.Description},
+		":name_lower":     &types.AttributeValueMemberS{Value: strings.ToLower(u.Name)},
+		":category_lower": &types.AttributeValueMemberS{Value: strings.ToLower(u.Category)},
+	}
+	if u.Price != nil {
+		updateExpr += ", price = :price"
+		values[":price"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(*u.Price, 10)}
+	}
+	if u.Currency != nil {
+		updateExpr += ", currency = :currency"
+		values[":currency"] = &types.AttributeValueMemberS{Value: *u.Currency}
+	}
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       productKey(u.ID),
+		UpdateExpression:          aws.String(updateExpr),
+		ConditionExpression:       aws.String("attribute_exists(PK)"),                                // only update an existing product
+		ExpressionAttributeNames:  map[string]string{"#name": "name", "#description": "description"}, // reserved words
+		ExpressionAttributeValues: values,
+	})
+	var condFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &condFailed) {
+		return fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, u.ID)
+	}
+	return err
+}
+
+// primary key of the product_id sequence (not under PRODUCT#, so searches do not see it)
+var productSequenceKey = map[string]types.AttributeValue{
+	"PK": &types.AttributeValueMemberS{Value: "SEQUENCE#PRODUCT"},
+	"SK": &types.AttributeValueMemberS{Value: "SEQUENCE"},
+}
+
+/* Internal function: reserve count new product IDs, returns the first one (the others follow it) */
+func (s *Store) nextProductIDs(ctx context.Context, count int) (int32, error) {
+	output, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       productSequenceKey,
+		UpdateExpression:          aws.String("ADD last_id :count"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":count": &types.AttributeValueMemberN{Value: strconv.Itoa(count)}},
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var sequence productSequence
+	if err := attributevalue.UnmarshalMap(output.Attributes, &sequence); err != nil {
+		return 0, err
+	}
+	return sequence.LastID - int32(count) + 1, nil
+}
+
+/* Internal function: move the product_id sequence past the generated products 1..number (never backwards) */
+func (s *Store) advanceProductSequence(ctx context.Context, number int) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       productSequenceKey,
+		UpdateExpression:          aws.String("SET last_id = :number"),
+		ConditionExpression:       aws.String("attribute_not_exists(last_id) OR last_id < :number"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":number": &types.AttributeValueMemberN{Value: strconv.Itoa(number)}},
+	})
+	var condFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &condFailed) {
+		return nil // already past the generated products
+	}
+	return err
+}
+
+/* Add a new product with the next product_id of the sequence */
+func (s *Store) CreateProduct(ctx context.Context, p store.Product) (store.Product, error) {
+	productID, err := s.nextProductIDs(ctx, 1)
+	if err != nil {
+		return store.Product{}, err
+	}
+	p.ID = productID
+	dbItem, err := attributevalue.MarshalMap(toProductData(p))
+	if err != nil {
+		return store.Product{}, fmt.Errorf("failed to marshal product: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.tableName),
+		Item:                dbItem,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"), // never overwrite a product
+	})
+	var condFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &condFailed) {
+		return store.Product{}, fmt.Errorf("product_id %d from the sequence is already used", productID)
+	}
+	if err != nil {
+		return store.Product{}, err
+	}
+	return toProductData(p).toProduct(), nil
+}
+
+/* Add new products with consecutive product IDs from the sequence (BatchWriteItem) */
+func (s *Store) ImportProducts(ctx context.Context, products []*store.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	firstID, err := s.nextProductIDs(ctx, len(products))
+	if err != nil {
+		return err
+	}
+	for i, p := range products {
+		p.ID = firstID + int32(i)
+	}
+	return s.saveProductsBatch(ctx, products)
+}
+
+// GSI over GSI2PK = PRODUCT#<product_id> / GSI2SK = CART#<uuid>, set on ITEM# rows (see terraform modules/dynamoDB)
+const productIndex = "GSI2-ProductIndex"
+
+// carts changed by one delete transaction: an ITEM# delete and a CART version update each, plus the PRODUCT delete
+const maxCartsPerDelete = 49
+
+// attempts to delete a product when the carts holding it change concurrently
+const deleteProductAttempts = 3
+
+/* Delete a product and its items in carts, whose version changes (the MySQL ON DELETE CASCADE)
+ * The carts holding the product are found with GSI2-ProductIndex, ErrProductInUse is returned if one of them is a placed order.
+ * Every ITEM# delete comes with a conditional version update of its CART row (still not an order), the PRODUCT row
+ * is deleted in the same transaction (the last one when more than maxCartsPerDelete carts hold the product). */
+func (s *Store) DeleteProduct(ctx context.Context, productID int32) error {
+	if _, err := s.GetProduct(ctx, productID); err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		holders, err := s.productHolders(ctx, productID)
+		if err != nil {
+			return err
+		}
+		for _, cart := range holders {
+			if store.IsOrderStatus(cart.Status) {
+				return fmt.Errorf("%w: product %d is in shopping cart %s (status '%s')", store.ErrProductInUse, productID, cart.CartID, cart.Status)
+			}
+		}
+
+		// a cart was checked out or locked meanwhile: look for the holders again
+		err = s.deleteProductFromCarts(ctx, productID, holders)
+		var cancelled *types.TransactionCanceledException
+		if !errors.As(err, &cancelled) {
+			return err
+		}
+		if !slices.ContainsFunc(cancelled.CancellationReasons, retryableCancellation) {
+			return fmt.Errorf("deleting product %d was cancelled (%s)", productID, cancellationCodes(cancelled.CancellationReasons))
+		}
+		if attempt == deleteProductAttempts {
+			return fmt.Errorf("%w: the carts holding product %d changed during %d attempts to delete it", store.ErrConcurrentUpdate, productID, deleteProductAttempts)
+		}
+		if err := sleepBackoff(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+/* Internal function: CART rows of the carts holding a product (Status is empty for ITEM# rows without a CART row) */
+func (s *Store) productHolders(ctx context.Context, productID int32) ([]cartMetadata, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		IndexName:              aws.String(productIndex),
+		KeyConditionExpression: aws.String("GSI2PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("PRODUCT#%d", productID)},
+		},
+	}
+	holders := make([]cartMetadata, 0)
+	for {
+		output, err := s.client.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, dbItem := range output.Items {
+			var item cartItemData
+			if err := attributevalue.UnmarshalMap(dbItem, &item); err != nil {
+				return nil, err
+			}
+			// the index is eventually consistent, the CART row is read consistently
+			cartID := strings.TrimPrefix(item.PK, "CART#")
+			meta, err := s.cartMetadata(ctx, item.PK, cartID)
+			if errors.Is(err, store.ErrCartNotFound) {
+				meta = cartMetadata{PK: item.PK, CartID: cartID}
+			} else if err != nil {
+				return nil, err
+			}
+			holders = append(holders, meta)
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return holders, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+/* Internal function: delete the ITEM# rows of a product from carts that are not orders (bumping their version) and the PRODUCT row */
+func (s *Store) deleteProductFromCarts(ctx context.Context, productID int32, holders []cartMetadata) error {
+	itemSK := &types.AttributeValueMemberS{Value: fmt.Sprintf("ITEM#%d", productID)}
+	for i := 0; i == 0 || i < len(holders); i += maxCartsPerDelete {
+		batch := holders[i:min(i+maxCartsPerDelete, len(holders))]
+		actions := make([]types.TransactWriteItem, 0, 2*len(batch)+1)
+		withProduct := i+maxCartsPerDelete >= len(holders)
+		if withProduct {
+			actions = append(actions, types.TransactWriteItem{Delete: &types.Delete{
+				TableName:           aws.String(s.tableName),
+				Key:                 productKey(productID),
+				ConditionExpression: aws.String("attribute_exists(PK)"),
+			}})
+		}
+		for _, cart := range batch {
+			cartPK := &types.AttributeValueMemberS{Value: cart.PK}
+			actions = append(actions, types.TransactWriteItem{Delete: &types.Delete{
+				TableName: aws.String(s.tableName),
+				Key:       map[string]types.AttributeValue{"PK": cartPK, "SK": itemSK},
+			}})
+			if cart.Status == "" {
+				continue // orphan item, no cart to update
+			}
+			actions = append(actions, types.TransactWriteItem{Update: &types.Update{
+				TableName:                aws.String(s.tableName),
+				Key:                      map[string]types.AttributeValue{"PK": cartPK, "SK": &types.AttributeValueMemberS{Value: "CART"}},
+				UpdateExpression:         aws.String("ADD version :one"),
+				ConditionExpression:      aws.String("#status IN (:active, :cancelled, :invalid)"),
+				ExpressionAttributeNames: map[string]string{"#status": "status"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":one":       &types.AttributeValueMemberN{Value: "1"},
+					":active":    &types.AttributeValueMemberS{Value: store.CartStatusActive},
+					":cancelled": &types.AttributeValueMemberS{Value: store.CartStatusCancelled},
+					":invalid":   &types.AttributeValueMemberS{Value: store.CartStatusInvalid},
+				},
+			}})
+		}
+
+		_, err := s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: actions})
+		var cancelled *types.TransactionCanceledException
+		if errors.As(err, &cancelled) && withProduct && conditionFailed(cancelled.CancellationReasons, 0) {
+			return fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, productID) // the PRODUCT row comes first
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Internal function: check if a cancellation reason may go away when the transaction is built again */
+func retryableCancellation(reason types.CancellationReason) bool {
+	code := aws.ToString(reason.Code)
+	return code == "ConditionalCheckFailed" || code == "TransactionConflict"
+}
+
+// GSI over GSI3PK = PRODUCT / GSI3SK = <product_id>, set on PRODUCT rows only (see terraform modules/dynamoDB)
+const catalogIndex = "GSI3-CatalogIndex"
+
+// GSI3PK of every product
+const catalogPartition = "PRODUCT"
+
+/* Search products by name, category, brand and description
+ * DynamoDB has no full-text index: every product is read from GSI3-CatalogIndex (page by page, carts and other items
+ * are not in the index) and matched in Go with store.MatchScore, the word prefix semantics of the other backends.
+ * Filters, facets, sorting and pagination are applied to all matches, so total_found and the facet counts are exact. */
+func (s *Store) Search(ctx context.Context, query store.SearchQuery) (store.SearchResult, error) {
+	start := time.Now()
+	terms := store.SearchTerms(query.Query)
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		IndexName:              aws.String(catalogIndex),
+		KeyConditionExpression: aws.String("GSI3PK = :catalog"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":catalog": &types.AttributeValueMemberS{Value: catalogPartition},
+		},
+	}
+	matches := make([]store.ScoredProduct, 0)
+	for {
+		output, err := s.client.Query(ctx, input)
+		if err != nil {
+			return store.SearchResult{}, err
+		}
+		for _, dbItem := range output.Items {
+			var item productData
+			if err := attributevalue.UnmarshalMap(dbItem, &item); err != nil {
+				return store.SearchResult{}, err
+			}
+			p := item.toProduct()
+			if score := store.MatchScore(&p, terms); score >= 0 {
+				matches = append(matches, store.ScoredProduct{Product: &p, Score: score})
+			}
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			break // end of the catalog
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+
+	result := store.PageSearchResults(matches, query)
+	result.SearchTime = fmt.Sprintf("%.6fs", time.Since(start).Seconds())
+	return result, nil
+}
+
+/* Internal function: add the GSI3-CatalogIndex keys to PRODUCT rows written before the index existed,
+ * so that Search finds them (a paged Scan of the PRODUCT rows without GSI3PK) */
+func (s *Store) indexCatalog(ctx context.Context) error {
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(s.tableName),
+		FilterExpression:          aws.String("SK = :product AND attribute_not_exists(GSI3PK)"),
+		ProjectionExpression:      aws.String("product_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":product": &types.AttributeValueMemberS{Value: "PRODUCT"}},
+	}
+	indexed := 0
+	for {
+		output, err := s.client.Scan(ctx, input)
+		if err != nil {
+			return err
+		}
+		for _, dbItem := range output.Items {
+			var item productData
+			if err := attributevalue.UnmarshalMap(dbItem, &item); err != nil {
+				return err
+			}
+			_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName:           aws.String(s.tableName),
+				Key:                 productKey(item.ProductID),
+				UpdateExpression:    aws.String("SET GSI3PK = :catalog, GSI3SK = :pid"),
+				ConditionExpression: aws.String("attribute_exists(PK)"), // the product may have been deleted meanwhile
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":catalog": &types.AttributeValueMemberS{Value: catalogPartition},
+					":pid":     &types.AttributeValueMemberN{Value: strconv.Itoa(int(item.ProductID))},
+				},
+			})
+			var condFailed *types.ConditionalCheckFailedException
+			if err != nil && !errors.As(err, &condFailed) {
+				return err
+			}
+			indexed++
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+	if indexed > 0 {
+		slog.InfoContext(ctx, "added products to the catalog index", "products", indexed, "index", catalogIndex)
+	}
+	return nil
+}
+
+/* Internal function: look up the products of a cart update, fails with *store.ProductsNotFoundError
+ * listing every unknown product_id. Returns the product names and prices by product_id. */
+func (s *Store) productSummaries(ctx context.Context, productIDs []int32) (map[int32]productData, error) {
+	summaries := make(map[int32]productData, len(productIDs))
+	for i := 0; i < len(productIDs); i += batchGetLimit {
+		batch := productIDs[i:min(i+batchGetLimit, len(productIDs))]
+
+		keys := make([]map[string]types.AttributeValue, 0, len(batch))
+		for _, pid := range batch {
+			keys = append(keys, productKey(pid))
+		}
+		request := map[string]types.KeysAndAttributes{
+			s.tableName: {
+				Keys:                     keys,
+				ProjectionExpression:     aws.String("product_id, #name, price, currency"),
+				ExpressionAttributeNames: map[string]string{"#name": "name"}, // "name" is a reserved word
+			},
+		}
+
+		// BatchGetItem may return part of the keys as unprocessed, ask again for them after a backoff
+		for retry := 0; len(request) > 0; retry++ {
+			if retry > 0 {
+				if err := sleepBackoff(ctx, retry); err != nil {
+					return nil, err
+				}
+			}
+			output, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return nil, err
+			}
+			for _, dbItem := range output.Responses[s.tableName] {
+				var item productData
+				if err := attributevalue.UnmarshalMap(dbItem, &item); err != nil {
+					return nil, err
+				}
+				summaries[item.ProductID] = item
+			}
+			request = output.UnprocessedKeys
+		}
+	}
+
+	missingProducts := make([]int32, 0)
+	for _, pid := range productIDs {
+		if _, ok := summaries[pid]; !ok {
+			missingProducts = append(missingProducts, pid)
+		}
+	}
+	if len(missingProducts) > 0 {
+		return nil, &store.ProductsNotFoundError{ProductIDs: missingProducts}
+	}
+	return summaries, nil
+}
+
+/* Store products if the catalog is empty
+ * Generated products have the IDs 1..n, so the catalog is empty if PRODUCT#1 does not exist.
+ * The product_id sequence is moved past them in any case (tables seeded before the sequence existed),
+ * and a catalog seeded before GSI3-CatalogIndex existed (PRODUCT#1 without GSI3PK) is added to the index. */
+func (s *Store) SeedIfEmpty(ctx context.Context, products []*store.Product) (bool, error) {
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String(s.tableName),
+		Key:                  productKey(1),
+		ProjectionExpression: aws.String("PK, GSI3PK"),
+	})
+	if err != nil {
+		return false, err
+	}
+	if _, ok := output.Item["GSI3PK"]; len(output.Item) > 0 && !ok {
+		if err := s.indexCatalog(ctx); err != nil {
+			return false, fmt.Errorf("failed to add products to %s: %w", catalogIndex, err)
+		}
+	}
+
+	seeded := false
+	if len(output.Item) == 0 {
+		if err := s.saveProductsBatch(ctx, products); err != nil {
+			return false, fmt.Errorf("failed to batch insert products: %w", err)
+		}
+		slog.InfoContext(ctx, "products inserted", "products", len(products), "table", s.tableName)
+		seeded = true
+	}
+
+	var lastID int32
+	for _, p := range products {
+		lastID = max(lastID, p.ID)
+	}
+	return seeded, s.advanceProductSequence(ctx, int(lastID))
+}
+
+/* Internal function: Store product items with BatchWriteItem */
+func (s *Store) saveProductsBatch(ctx context.Context, products []*store.Product) error {
+	writeRequests := make([]types.WriteRequest, 0, len(products))
+	for _, p := range products {
+		dbItem, err := attributevalue.MarshalMap(toProductData(*p))
+		if err != nil {
+			return fmt.Errorf("failed to marshal product: %w", err)
+		}
+		writeRequests = append(writeRequests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: dbItem},
+		})
+	}
+	return s.batchWrite(ctx, writeRequests)
+}
+
+// compile-time check
+var _ store.ProductStore = (*Store)(nil)
+var _ store.ProductWriter = (*Store)(nil)
+var _ store.Seeder = (*Store)(nil)
@@ -0,0 +1,73 @@
+package dynamostore
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"hw8-onlinestore/store"
+)
+
+func TestSearchReadsWholeCatalog(t *testing.T) {
+	products := []store.Product{
+		{ID: 1, Name: "Desk Lamp", Category: "Home", Brand: "Brightly", Description: "LED lamp"},
+		{ID: 2, Name: "Office Chair", Category: "Furniture", Brand: "Sitwell", Description: "Ergonomic chair with lamp holder"},
+		{ID: 3, Name: "Notebook", Category: "Office", Brand: "Brightly", Description: "Paper"},
+		{ID: 4, Name: "Floor Lamp", Category: "Home", Brand: "Lumen", Description: "Tall lamp"},
+	}
+	pages := make([][]map[string]types.AttributeValue, 2)
+	for i, p := range products {
+		item, err := attributevalue.MarshalMap(toProductData(p))
+		if err != nil {
+			t.Fatalf("failed to marshal product: %v", err)
+		}
+		pages[i/2] = append(pages[i/2], item)
+	}
+
+	// the catalog index is read in two pages
+	s := newTestStore(&fakeClient{
+		query: func(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			if aws.ToString(input.IndexName) != catalogIndex {
+				t.Fatalf("expected a query of %s, got %s", catalogIndex, aws.ToString(input.IndexName))
+			}
+			if input.ExclusiveStartKey == nil {
+				return &dynamodb.QueryOutput{Items: pages[0], LastEvaluatedKey: pages[0][1]}, nil
+			}
+			return &dynamodb.QueryOutput{Items: pages[1]}, nil
+		},
+	})
+
+	tests := []struct {
+		name    string
+		query   store.SearchQuery
+		wantIDs []int32
+		total   int
+	}{
+		// word prefixes match in name, category, brand and description
+		{"description", store.SearchQuery{Query: "lam", Sort: store.SortID, Page: 1, PageSize: 10}, []int32{1, 2, 4}, 3},
+		{"brand", store.SearchQuery{Query: "bright", Sort: store.SortID, Page: 1, PageSize: 10}, []int32{1, 3}, 2},
+		{"not a word prefix", store.SearchQuery{Query: "amp", Sort: store.SortID, Page: 1, PageSize: 10}, []int32{}, 0},
+		{"second page", store.SearchQuery{Query: "lamp", Sort: store.SortID, Page: 2, PageSize: 2}, []int32{4}, 3},
+		{"most relevant first", store.SearchQuery{Query: "lamp", Sort: store.SortRelevance, Page: 1, PageSize: 1}, []int32{1}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.Search(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("failed to search: %v", err)
+			}
+			ids := make([]int32, 0, len(result.Products))
+			for _, p := range result.Products {
+				ids = append(ids, p.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || result.TotalFound != tt.total {
+				t.Fatalf("expected products %v of %d, got %v of %d", tt.wantIDs, tt.total, ids, result.TotalFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// define sample arrays (defaults of ProductSpec)
+var (
+	DefaultBrands     = []string{"Alpha", "Beta", "Gamma", "Delta", "Epsilon", "Zeta"}
+	DefaultCategories = []string{"Electronics", "Books", "Home", "Food", "Toy", "Office Supplies", "Health", "Personal Care"}
+)
+
+// define ProductSpec struct (what GenerateProducts builds)
+// the same spec always generates the same products
+type ProductSpec struct {
+	Count      int
+	Brands     []string
+	Categories []string
+	Seed       uint64
+}
+
+/* GenerateProducts builds spec.Count sample products with IDs 1..Count
+ * Brand, category and price (0.99 to 99.99 USD) are drawn from a random generator seeded with spec.Seed */
+func GenerateProducts(spec ProductSpec) []*Product {
+	brands := spec.Brands
+	if len(brands) == 0 {
+		brands = DefaultBrands
+	}
+	categories := spec.Categories
+	if len(categories) == 0 {
+		categories = DefaultCategories
+	}
+	rng := rand.New(rand.NewPCG(spec.Seed, spec.Seed))
+
+	// initialize a slice to store Product pointers
+	productPtrs := make([]*Product, 0, spec.Count)
+
+	// generate product data
+	for i := 1; i <= spec.Count; i++ {
+		brand := brands[rng.IntN(len(brands))]
+		category := categories[rng.IntN(len(categories))]
+		name := fmt.Sprintf("Product %s %d", brand, i)
+		p := &Product{
+			ID:            int32(i),
+			Name:          name,
+			Category:      category,
+			Description:   "",
+			Brand:         brand,
+			Price:         int64(99 + rng.IntN(100)*100),
+			Currency:      DefaultCurrency,
+			NameLower:     strings.ToLower(name),
+			CategoryLower: strings.ToLower(category),
+		}
+		productPtrs = append(productPtrs, p)
+	}
+	return productPtrs
+}
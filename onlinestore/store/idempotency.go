@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+/* IdempotentResponse is the response stored for an Idempotency-Key
+ * StatusCode is 0 while the first request with the key is still being processed */
+type IdempotentResponse struct {
+	Fingerprint string // hash of the request (method, path and body) that claimed the key
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+}
+
+/* IdempotencyStore is implemented by backends that can remember responses per Idempotency-Key
+ * ClaimIdempotencyKey records key as in progress until now + lease and returns nil, or returns the record
+ * already stored for the key (in progress or completed) without changing it. Expired records can be claimed again.
+ * SaveIdempotentResponse stores the response of the request that claimed the key, kept until now + ttl.
+ * ReleaseIdempotencyKey forgets a key that is still in progress (the request failed and may be retried). */
+type IdempotencyStore interface {
+	ClaimIdempotencyKey(ctx context.Context, key string, fingerprint string, lease time.Duration) (*IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, key string, resp IdempotentResponse, ttl time.Duration) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
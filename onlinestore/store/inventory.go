@@ -0,0 +1,12 @@
+package store
+
+/* Reserve computes the reservation of a stock-managed product when a cart changes its quantity from held to quantity
+ * It returns the new reserved total and the quantity available to the cart (its own reservation included).
+ * The math is signed and clamped: a cart never gives back more than the product has reserved,
+ * and nothing is available when reservations already exceed the stock. */
+func Reserve(stock uint, reserved uint, held uint, quantity uint) (uint, uint) {
+	released := min(int64(held), int64(reserved))
+	others := int64(reserved) - released
+	available := max(int64(stock)-others, 0)
+	return uint(others + int64(quantity)), uint(available)
+}
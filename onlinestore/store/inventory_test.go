@@ -0,0 +1,27 @@
+package store
+
+import "testing"
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name                            string
+		stock, reserved, held, quantity uint
+		wantReserved, wantAvailable     uint
+	}{
+		{"new item", 10, 3, 0, 2, 5, 7},
+		{"increase", 10, 3, 2, 4, 5, 9},
+		{"decrease", 10, 3, 2, 1, 2, 9},
+		{"remove", 10, 3, 2, 0, 1, 9},
+		{"held more than reserved", 10, 0, 2, 1, 1, 10},
+		{"reserved above stock", 2, 5, 1, 1, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reserved, available := Reserve(tt.stock, tt.reserved, tt.held, tt.quantity)
+			if reserved != tt.wantReserved || available != tt.wantAvailable {
+				t.Fatalf("Reserve(%d, %d, %d, %d) = %d, %d, want %d, %d",
+					tt.stock, tt.reserved, tt.held, tt.quantity, reserved, available, tt.wantReserved, tt.wantAvailable)
+			}
+		})
+	}
+}
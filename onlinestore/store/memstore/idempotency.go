@@ -0,0 +1,72 @@
+package memstore
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"hw8-onlinestore/store"
+)
+
+// internal idempotency record
+type idempotencyRecord struct {
+	resp      store.IdempotentResponse
+	expiresAt time.Time
+}
+
+/* Claim an Idempotency-Key, or return the record already stored for it (see store.IdempotencyStore) */
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key string, fingerprint string, lease time.Duration) (*store.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if r, ok := s.idempotency[key]; ok && r.expiresAt.After(now) {
+		return copyResponse(r.resp), nil
+	}
+
+	// drop expired records while holding the lock anyway
+	for k, r := range s.idempotency {
+		if !r.expiresAt.After(now) {
+			delete(s.idempotency, k)
+		}
+	}
+	s.idempotency[key] = &idempotencyRecord{
+		resp:      store.IdempotentResponse{Fingerprint: fingerprint},
+		expiresAt: now.Add(lease),
+	}
+	return nil, nil
+}
+
+/* Store the response of the request that claimed key */
+func (s *Store) SaveIdempotentResponse(ctx context.Context, key string, resp store.IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.idempotency[key]; ok && r.resp.Fingerprint == resp.Fingerprint {
+		r.resp = *copyResponse(resp)
+		r.expiresAt = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+/* Forget a key that is still in progress */
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.idempotency[key]; ok && r.resp.StatusCode == 0 {
+		delete(s.idempotency, key)
+	}
+	return nil
+}
+
+/* Internal function: deep copy of a response (records must not share their body or headers with callers) */
+func copyResponse(resp store.IdempotentResponse) *store.IdempotentResponse {
+	out := resp
+	out.Headers = maps.Clone(resp.Headers)
+	out.Body = append([]byte(nil), resp.Body...)
+	return &out
+}
+
+// compile-time check
+var _ store.IdempotencyStore = (*Store)(nil)
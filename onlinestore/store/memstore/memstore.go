@@ -0,0 +1,500 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hw8-onlinestore/store"
+)
+
+/* Store is a concurrency-safe in-memory implementation of store.CartStore and store.ProductStore
+ * It follows the MySQL semantics: numeric cart IDs, one active cart per customer,
+ * ON DUPLICATE KEY-style item upserts and PRODUCT_NOT_FOUND for unknown products. */
+type Store struct {
+	mu            sync.Mutex
+	products      map[int32]store.Product
+	inventory     map[int32]*stockLevel // only stock-managed products
+	carts         map[uint64]*cart
+	active        map[uint64]uint64 // customer_id -> active cart_id
+	nextCartID    uint64
+	lastProductID int32 // highest product_id ever used (like AUTO_INCREMENT, not reused after a delete)
+
+	idempotency map[string]*idempotencyRecord // Idempotency-Key -> stored response
+}
+
+// internal cart record, items and their unit prices (snapshot when added) are keyed by product_id
+type cart struct {
+	id         uint64
+	customerID uint64
+	status     string
+	version    uint64
+	currency   string
+	items      map[int32]uint
+	prices     map[int32]int64
+}
+
+// internal inventory record
+type stockLevel struct {
+	stock    uint
+	reserved uint
+}
+
+/* New returns an empty Store */
+func New() *Store {
+	return &Store{
+		products:   make(map[int32]store.Product),
+		inventory:  make(map[int32]*stockLevel),
+		carts:      make(map[uint64]*cart),
+		active:     make(map[uint64]uint64),
+		nextCartID: 1,
+
+		idempotency: make(map[string]*idempotencyRecord),
+	}
+}
+
+/* AddProducts inserts or replaces products (used to seed the catalog) */
+func (s *Store) AddProducts(products []*store.Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range products {
+		s.products[p.ID] = *p
+		s.lastProductID = max(s.lastProductID, p.ID)
+	}
+}
+
+/* Store products if the catalog is empty */
+func (s *Store) SeedIfEmpty(ctx context.Context, products []*store.Product) (bool, error) {
+	s.mu.Lock()
+	empty := len(s.products) == 0
+	s.mu.Unlock()
+
+	if !empty {
+		return false, nil
+	}
+	s.AddProducts(products)
+	return true, nil
+}
+
+/* Internal function: parse a cart ID from its string form (positive integer) */
+func parseCartID(cartIDStr string) (uint64, error) {
+	cartID, err := strconv.ParseUint(cartIDStr, 10, 64)
+	if (err != nil) || (cartID < 1) {
+		return 0, fmt.Errorf("%w: shopping cart ID must be an positive integer >= 1 (input: %s)", store.ErrInvalidCartID, cartIDStr)
+	}
+	return cartID, nil
+}
+
+/* Creates a new shopping cart if the customer does not have an active shopping cart */
+func (s *Store) Create(ctx context.Context, customerID uint64) (store.Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cartID, ok := s.active[customerID]; ok { // active cart already exists for this customer
+		return store.Cart{}, &store.ActiveCartExistsError{
+			CustomerID: customerID,
+			CartID:     strconv.FormatUint(cartID, 10),
+		}
+	}
+
+	c := &cart{
+		id:         s.nextCartID,
+		customerID: customerID,
+		status:     store.CartStatusActive,
+		version:    1,
+		items:      make(map[int32]uint),
+		prices:     make(map[int32]int64),
+	}
+	s.carts[c.id] = c
+	s.active[customerID] = c.id
+	s.nextCartID++
+
+	return s.toCart(c), nil
+}
+
+/* Get a shopping cart with its items */
+func (s *Store) Get(ctx context.Context, cartIDStr string) (store.Cart, error) {
+	cartID, err := parseCartID(cartIDStr)
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.carts[cartID]
+	if !ok {
+		return store.Cart{}, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartIDStr)
+	}
+	return s.toCart(c), nil
+}
+
+/* Add, update or remove (quantity 0) items in existing cart, all or nothing */
+func (s *Store) UpsertItems(ctx context.Context, cartIDStr string, items []store.ItemUpdate, ifVersion uint64) (uint64, error) {
+	cartID, err := parseCartID(cartIDStr)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.carts[cartID]
+	if !ok {
+		return 0, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartIDStr)
+	}
+	if ifVersion != 0 && c.version != ifVersion {
+		return 0, fmt.Errorf("%w: shopping cart %s is at version %d (expected: %d)", store.ErrVersionMismatch, cartIDStr, c.version, ifVersion)
+	}
+	if c.status != store.CartStatusActive {
+		return 0, fmt.Errorf("%w: shopping cart %s is '%s'", store.ErrCartNotActive, cartIDStr, c.status)
+	}
+
+	// check if all products exist before touching the cart
+	missingProducts := make([]int32, 0)
+	for _, item := range items {
+		if _, ok := s.products[item.ProductID]; !ok {
+			missingProducts = append(missingProducts, item.ProductID)
+		}
+	}
+	if len(missingProducts) > 0 {
+		return 0, &store.ProductsNotFoundError{ProductIDs: missingProducts}
+	}
+
+	// products new to the cart must be priced in the cart currency
+	added := make(map[int32]string)
+	for _, item := range items {
+		if _, ok := c.items[item.ProductID]; !ok && item.Quantity > 0 {
+			added[item.ProductID] = s.products[item.ProductID].Currency
+		}
+	}
+	currency, err := store.CartCurrency(cartIDStr, c.currency, added)
+	if err != nil {
+		return 0, err
+	}
+
+	// check reservations of stock-managed products before applying any of them
+	for _, item := range items {
+		if inv, ok := s.inventory[item.ProductID]; ok {
+			if _, available := store.Reserve(inv.stock, inv.reserved, c.items[item.ProductID], item.Quantity); item.Quantity > available {
+				return 0, &store.InsufficientStockError{ProductID: item.ProductID, Requested: item.Quantity, Available: available}
+			}
+		}
+	}
+
+	for _, item := range items {
+		if inv, ok := s.inventory[item.ProductID]; ok {
+			inv.reserved, _ = store.Reserve(inv.stock, inv.reserved, c.items[item.ProductID], item.Quantity)
+		}
+		if item.Quantity == 0 {
+			delete(c.items, item.ProductID)
+			delete(c.prices, item.ProductID)
+			continue
+		}
+		if _, ok := c.items[item.ProductID]; !ok {
+			c.prices[item.ProductID] = s.products[item.ProductID].Price
+		}
+		c.items[item.ProductID] = item.Quantity
+	}
+	c.currency = currency
+	c.version++
+	return c.version, nil
+}
+
+/* Move a cart to another status (see store.CartStore), inventory is adjusted under the same lock */
+func (s *Store) Transition(ctx context.Context, cartIDStr string, to string) (store.Cart, error) {
+	cartID, err := parseCartID(cartIDStr)
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.carts[cartID]
+	if !ok {
+		return store.Cart{}, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartIDStr)
+	}
+	if !store.CanTransition(c.status, to) {
+		return store.Cart{}, &store.InvalidTransitionError{CartID: cartIDStr, From: c.status, To: to}
+	}
+
+	switch to {
+	case store.CartStatusOrdered:
+		if len(c.items) == 0 {
+			return store.Cart{}, fmt.Errorf("%w: shopping cart %s can not be checked out", store.ErrCartEmpty, cartIDStr)
+		}
+	case store.CartStatusCancelled:
+		for pid, qty := range c.items {
+			if inv, ok := s.inventory[pid]; ok {
+				inv.reserved -= min(inv.reserved, qty)
+			}
+		}
+	case store.CartStatusPaid:
+		for pid, qty := range c.items {
+			if inv, ok := s.inventory[pid]; ok {
+				inv.reserved -= min(inv.reserved, qty)
+				inv.stock -= min(inv.stock, qty)
+			}
+		}
+	}
+
+	if c.status == store.CartStatusActive {
+		delete(s.active, c.customerID)
+	}
+	c.status = to
+	c.version++
+	return s.toCart(c), nil
+}
+
+// position of a cart listing page (same format as MySQL: keyset on cart_id)
+type cartCursor struct {
+	CartID uint64 `json:"cart_id"`
+}
+
+/* List the carts of a customer, newest first */
+func (s *Store) ListCarts(ctx context.Context, query store.CartListQuery) (store.CartPage, error) {
+	before := uint64(math.MaxUint64)
+	if query.Cursor != "" {
+		var position cartCursor
+		if err := store.DecodeCursor(query.Cursor, &position); err != nil {
+			return store.CartPage{}, err
+		}
+		before = position.CartID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := make([]*cart, 0)
+	for _, c := range s.carts {
+		if c.customerID == query.CustomerID && c.id < before && (query.Status == "" || c.status == query.Status) {
+			matches = append(matches, c)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].id > matches[j].id })
+
+	page := store.CartPage{Carts: make([]store.CartSummary, 0, min(len(matches), query.Limit))}
+	for _, c := range matches[:min(len(matches), query.Limit)] {
+		page.Carts = append(page.Carts, store.CartSummary{
+			CartID:     strconv.FormatUint(c.id, 10),
+			CustomerID: c.customerID,
+			Status:     c.status,
+		})
+	}
+	if len(matches) > query.Limit {
+		cursor, err := store.EncodeCursor(cartCursor{CartID: matches[query.Limit-1].id})
+		if err != nil {
+			return store.CartPage{}, err
+		}
+		page.NextCursor = cursor
+	}
+	return page, nil
+}
+
+/* Internal function: copy a cart record into its API form (caller holds s.mu) */
+func (s *Store) toCart(c *cart) store.Cart {
+	out := store.Cart{
+		CartID:     strconv.FormatUint(c.id, 10),
+		CustomerID: c.customerID,
+		Status:     c.status,
+		Version:    c.version,
+		Currency:   c.currency,
+		Items:      make([]store.CartItem, 0, len(c.items)),
+	}
+	for pid, qty := range c.items {
+		out.Items = append(out.Items, store.CartItem{
+			ProductID:   pid,
+			ProductName: s.products[pid].Name,
+			Quantity:    qty,
+			UnitPrice:   c.prices[pid],
+		})
+	}
+	sort.Slice(out.Items, func(i, j int) bool { return out.Items[i].ProductID < out.Items[j].ProductID })
+	out.SetTotals()
+	return out
+}
+
+/* Delete all carts (keep product data) */
+func (s *Store) ClearCarts(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.carts = make(map[uint64]*cart)
+	s.active = make(map[uint64]uint64)
+	for _, inv := range s.inventory {
+		inv.reserved = 0
+	}
+	return nil
+}
+
+/* Get the inventory record of a product */
+func (s *Store) GetInventory(ctx context.Context, productID int32) (store.Inventory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.inventory[productID]
+	if !ok {
+		return store.Inventory{}, fmt.Errorf("%w: product %d is not stock-managed", store.ErrInventoryNotFound, productID)
+	}
+	return toInventory(productID, inv), nil
+}
+
+/* Create or update the stock of a product, keeping its current reservations
+ * A product that becomes stock-managed starts with the quantities already in active carts reserved */
+func (s *Store) SetStock(ctx context.Context, productID int32, stock uint) (store.Inventory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.products[productID]; !ok {
+		return store.Inventory{}, fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, productID)
+	}
+	inv, ok := s.inventory[productID]
+	if !ok {
+		inv = &stockLevel{}
+		for _, c := range s.carts {
+			if c.status == store.CartStatusActive {
+				inv.reserved += c.items[productID]
+			}
+		}
+	}
+	if stock < inv.reserved {
+		return store.Inventory{}, fmt.Errorf("%w: product %d has %d reserved units (requested stock: %d)", store.ErrStockBelowReserved, productID, inv.reserved, stock)
+	}
+	inv.stock = stock
+	s.inventory[productID] = inv
+	return toInventory(productID, inv), nil
+}
+
+/* Internal function: copy an inventory record into its API form (caller holds s.mu) */
+func toInventory(productID int32, inv *stockLevel) store.Inventory {
+	return store.Inventory{
+		ProductID: productID,
+		Stock:     inv.stock,
+		Reserved:  inv.reserved,
+		Available: inv.stock - inv.reserved,
+	}
+}
+
+/* Get product by ID */
+func (s *Store) GetProduct(ctx context.Context, productID int32) (store.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.products[productID]
+	if !ok {
+		return store.Product{}, fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, productID)
+	}
+	return p, nil
+}
+
+/* Update product information if the product exists (price and currency are kept unless the update sets them) */
+func (s *Store) UpdateProduct(ctx context.Context, u store.ProductUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.products[u.ID]
+	if !ok {
+		return fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, u.ID)
+	}
+	p := u.Apply(stored)
+	p.NameLower = strings.ToLower(p.Name)
+	p.CategoryLower = strings.ToLower(p.Category)
+	s.products[p.ID] = p
+	return nil
+}
+
+/* Add a new product with the next product_id */
+func (s *Store) CreateProduct(ctx context.Context, p store.Product) (store.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastProductID++
+	p.ID = s.lastProductID
+	p.NameLower = strings.ToLower(p.Name)
+	p.CategoryLower = strings.ToLower(p.Category)
+	s.products[p.ID] = p
+	return p, nil
+}
+
+/* Add new products with consecutive product IDs */
+func (s *Store) ImportProducts(ctx context.Context, products []*store.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range products {
+		s.lastProductID++
+		p.ID = s.lastProductID
+		p.NameLower = strings.ToLower(p.Name)
+		p.CategoryLower = strings.ToLower(p.Category)
+		s.products[p.ID] = *p
+	}
+	return nil
+}
+
+/* Delete a product, its inventory and its items in carts (like the MySQL ON DELETE CASCADE)
+ * Products in placed orders are kept, so orders do not lose items */
+func (s *Store) DeleteProduct(ctx context.Context, productID int32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.products[productID]; !ok {
+		return fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, productID)
+	}
+	holders := make([]*cart, 0)
+	for _, c := range s.carts {
+		if _, ok := c.items[productID]; !ok {
+			continue
+		}
+		if store.IsOrderStatus(c.status) {
+			return fmt.Errorf("%w: product %d is in shopping cart %d (status '%s')", store.ErrProductInUse, productID, c.id, c.status)
+		}
+		holders = append(holders, c)
+	}
+
+	for _, c := range holders {
+		delete(c.items, productID)
+		delete(c.prices, productID)
+		c.version++
+	}
+	delete(s.inventory, productID)
+	delete(s.products, productID)
+	return nil
+}
+
+/* Search products matching every term of the query as a word prefix in name, category, brand or description
+ * (the MySQL FULLTEXT semantics) and the filters, relevance is the number of matching words */
+func (s *Store) Search(ctx context.Context, query store.SearchQuery) (store.SearchResult, error) {
+	start := time.Now()
+	terms := store.SearchTerms(query.Query)
+
+	s.mu.Lock()
+	matches := make([]store.ScoredProduct, 0)
+	for _, p := range s.products {
+		if score := store.MatchScore(&p, terms); score >= 0 {
+			matches = append(matches, store.ScoredProduct{Product: &p, Score: score})
+		}
+	}
+	s.mu.Unlock()
+
+	result := store.PageSearchResults(matches, query)
+	result.SearchTime = fmt.Sprintf("%.6fs", time.Since(start).Seconds())
+	return result, nil
+}
+
+// compile-time checks
+var (
+	_ store.CartStore      = (*Store)(nil)
+	_ store.CartLister     = (*Store)(nil)
+	_ store.ProductStore   = (*Store)(nil)
+	_ store.ProductWriter  = (*Store)(nil)
+	_ store.CartClearer    = (*Store)(nil)
+	_ store.InventoryStore = (*Store)(nil)
+	_ store.Seeder         = (*Store)(nil)
+)
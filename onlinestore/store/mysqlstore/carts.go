@@ -0,0 +1,449 @@
+package mysqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"hw8-onlinestore/store"
+)
+
+/* Internal function: parse a cart ID from its string form (positive integer) */
+func parseCartID(cartIDStr string) (uint64, error) {
+	cartID, err := strconv.ParseUint(cartIDStr, 10, 64)
+	if (err != nil) || (cartID < 1) {
+		return 0, fmt.Errorf("%w: shopping cart ID must be an positive integer >= 1 (input: %s)", store.ErrInvalidCartID, cartIDStr)
+	}
+	return cartID, nil
+}
+
+/* Creates a new shopping cart if the customer does not have an active shopping cart */
+func (s *Store) Create(ctx context.Context, customerID uint64) (store.Cart, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Cart{}, err
+	}
+	defer tx.Rollback()
+
+	var cartID uint64
+	err = tx.QueryRowContext(ctx, `
+		SELECT cart_id
+		FROM shopping_cart
+		WHERE customer_id = ? AND status = 'active'
+		FOR UPDATE
+	`, customerID).Scan(&cartID)
+
+	if err != nil && err != sql.ErrNoRows {
+		return store.Cart{}, err
+	}
+
+	if err == nil { // active cart already exists for this customer
+		return store.Cart{}, &store.ActiveCartExistsError{
+			CustomerID: customerID,
+			CartID:     strconv.FormatUint(cartID, 10),
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO shopping_cart (customer_id) VALUES (?)", customerID)
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	// get the return cart_id
+	lastID, err := res.LastInsertId()
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Cart{}, err
+	}
+
+	return store.Cart{
+		CartID:     strconv.FormatInt(lastID, 10),
+		CustomerID: customerID,
+		Status:     store.CartStatusActive,
+		Version:    1,
+		Items:      []store.CartItem{},
+	}, nil
+}
+
+/* Get a shopping cart with its items */
+func (s *Store) Get(ctx context.Context, cartIDStr string) (store.Cart, error) {
+	cartID, err := parseCartID(cartIDStr)
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	var cart store.Cart
+	var currency sql.NullString
+	var itemsJSON []byte
+
+	// get shopping cart information and items from the database
+	// use JSON_ARRAYAGG to aggregate results into JSON objects directly
+	row := s.db.QueryRowContext(ctx, `
+		SELECT
+			sc.cart_id,
+			sc.customer_id,
+			sc.status,
+			sc.version,
+			sc.currency,
+			IF(COUNT(ci.product_id) = 0, JSON_ARRAY(),
+				JSON_ARRAYAGG(
+					JSON_OBJECT(
+						'product_id', ci.product_id,
+						'product_name', p.name,
+						'quantity', ci.quantity,
+						'unit_price', ci.unit_price
+					)
+				)
+			) AS items
+		FROM shopping_cart sc
+		LEFT JOIN cart_item ci ON sc.cart_id = ci.cart_id
+		LEFT JOIN product p ON ci.product_id = p.product_id
+		WHERE sc.cart_id = ?
+		GROUP BY sc.cart_id, sc.customer_id, sc.status, sc.version, sc.currency;
+	`, cartID) // query the database once, no transcation needed
+
+	err = row.Scan(&cart.CartID, &cart.CustomerID, &cart.Status, &cart.Version, &currency, &itemsJSON)
+	if err == sql.ErrNoRows {
+		return store.Cart{}, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartIDStr)
+	} else if err != nil {
+		return store.Cart{}, err
+	}
+
+	// parse the JSON objects into Go slice
+	cart.Items = []store.CartItem{}
+	if err := json.Unmarshal(itemsJSON, &cart.Items); err != nil {
+		return store.Cart{}, fmt.Errorf("failed to parse cart items JSON: %w", err)
+	}
+	cart.Currency = currency.String
+	cart.SetTotals()
+	return cart, nil
+}
+
+// position of a cart listing page (keyset on cart_id)
+type cartCursor struct {
+	CartID uint64 `json:"cart_id"`
+}
+
+/* List the carts of a customer, newest first (keyset pagination on cart_id)
+ * Uses idx_customer_id, or idx_status_customer when filtering by status */
+func (s *Store) ListCarts(ctx context.Context, query store.CartListQuery) (store.CartPage, error) {
+	conditions := []string{"customer_id = ?"}
+	args := []any{query.CustomerID}
+	if query.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, query.Status)
+	}
+	if query.Cursor != "" {
+		var position cartCursor
+		if err := store.DecodeCursor(query.Cursor, &position); err != nil {
+			return store.CartPage{}, err
+		}
+		conditions = append(conditions, "cart_id < ?")
+		args = append(args, position.CartID)
+	}
+	// one extra row tells if there is a next page
+	args = append(args, query.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT cart_id, customer_id, status
+		FROM shopping_cart
+		WHERE %s
+		ORDER BY cart_id DESC
+		LIMIT ?
+	`, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return store.CartPage{}, err
+	}
+	defer rows.Close()
+
+	page := store.CartPage{Carts: make([]store.CartSummary, 0, query.Limit)}
+	var lastID uint64
+	for rows.Next() {
+		if len(page.Carts) == query.Limit {
+			cursor, err := store.EncodeCursor(cartCursor{CartID: lastID})
+			if err != nil {
+				return store.CartPage{}, err
+			}
+			page.NextCursor = cursor
+			break
+		}
+		var cartID uint64
+		var cart store.CartSummary
+		if err := rows.Scan(&cartID, &cart.CustomerID, &cart.Status); err != nil {
+			return store.CartPage{}, err
+		}
+		cart.CartID = strconv.FormatUint(cartID, 10)
+		page.Carts = append(page.Carts, cart)
+		lastID = cartID
+	}
+	return page, rows.Err()
+}
+
+/* Add, update or remove (quantity 0) items in existing cart (handle product references and quantities)
+ * The cart version is checked against ifVersion (if not 0) and increased in the same transaction */
+func (s *Store) UpsertItems(ctx context.Context, cartIDStr string, items []store.ItemUpdate, ifVersion uint64) (uint64, error) {
+	cartID, err := parseCartID(cartIDStr)
+	if err != nil {
+		return 0, err
+	}
+
+	// start transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// check if the shopping cart exists, is active and still at the expected version, lock the shopping cart row
+	var status string
+	var version uint64
+	var currency sql.NullString
+	err = tx.QueryRowContext(ctx, "SELECT status, version, currency FROM shopping_cart WHERE cart_id=? FOR UPDATE", cartID).Scan(&status, &version, &currency)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartIDStr)
+	} else if err != nil {
+		return 0, err
+	}
+	if ifVersion != 0 && version != ifVersion {
+		return 0, fmt.Errorf("%w: shopping cart %s is at version %d (expected: %d)", store.ErrVersionMismatch, cartIDStr, version, ifVersion)
+	}
+	if status != store.CartStatusActive {
+		return 0, fmt.Errorf("%w: shopping cart %s is '%s'", store.ErrCartNotActive, cartIDStr, status)
+	}
+
+	// check if all product_id listed in the request are valid and lock the involved product rows
+	prices, err := lockProducts(ctx, tx, items)
+	if err != nil {
+		return 0, err
+	}
+
+	// products new to the cart must be priced in the cart currency
+	inCart, err := itemsInCart(ctx, tx, cartID, items)
+	if err != nil {
+		return 0, err
+	}
+	added := make(map[int32]string)
+	for _, item := range items {
+		if !inCart[item.ProductID] && item.Quantity > 0 {
+			added[item.ProductID] = prices[item.ProductID].currency
+		}
+	}
+	cartCurrency, err := store.CartCurrency(cartIDStr, currency.String, added)
+	if err != nil {
+		return 0, err
+	}
+
+	// reserve stock for stock-managed products (fails the whole update if any product is short)
+	// removed items (quantity 0) release their whole reservation
+	if err := reserveItems(ctx, tx, cartID, items); err != nil {
+		return 0, err
+	}
+
+	// add or update items, remove items with quantity 0 (cart_item has CHECK (quantity > 0))
+	// the unit price is only written for new items, existing items keep the price they were added at
+	upsert, err := tx.PrepareContext(ctx, `
+		INSERT INTO cart_item (product_id, quantity, cart_id, unit_price)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE quantity = VALUES(quantity)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer upsert.Close()
+
+	for _, item := range items {
+		if item.Quantity == 0 {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM cart_item WHERE cart_id = ? AND product_id = ?", cartID, item.ProductID); err != nil {
+				return 0, fmt.Errorf("failed to remove item %d: %w", item.ProductID, err)
+			}
+			continue
+		}
+		if _, err := upsert.ExecContext(ctx, item.ProductID, item.Quantity, cartID, prices[item.ProductID].price); err != nil {
+			return 0, fmt.Errorf("failed to add/update item %d: %w", item.ProductID, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE shopping_cart SET version = version + 1, currency = NULLIF(?, '') WHERE cart_id = ?", cartCurrency, cartID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
+
+/* Move a cart to another status (see store.CartStore), inventory is adjusted in the same transaction */
+func (s *Store) Transition(ctx context.Context, cartIDStr string, to string) (store.Cart, error) {
+	cartID, err := parseCartID(cartIDStr)
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Cart{}, err
+	}
+	defer tx.Rollback()
+
+	// lock the shopping cart row and check the transition
+	cart := store.Cart{CartID: cartIDStr}
+	var currency sql.NullString
+	err = tx.QueryRowContext(ctx, "SELECT customer_id, status, version, currency FROM shopping_cart WHERE cart_id=? FOR UPDATE", cartID).Scan(&cart.CustomerID, &cart.Status, &cart.Version, &currency)
+	if err == sql.ErrNoRows {
+		return store.Cart{}, fmt.Errorf("%w: shopping cart %s does not exist", store.ErrCartNotFound, cartIDStr)
+	} else if err != nil {
+		return store.Cart{}, err
+	}
+	if !store.CanTransition(cart.Status, to) {
+		return store.Cart{}, &store.InvalidTransitionError{CartID: cartIDStr, From: cart.Status, To: to}
+	}
+
+	cart.Items, err = cartItems(ctx, tx, cartID)
+	if err != nil {
+		return store.Cart{}, err
+	}
+
+	switch to {
+	case store.CartStatusOrdered:
+		if len(cart.Items) == 0 {
+			return store.Cart{}, fmt.Errorf("%w: shopping cart %s can not be checked out", store.ErrCartEmpty, cartIDStr)
+		}
+	case store.CartStatusCancelled:
+		if err := releaseItems(ctx, tx, cart.Items); err != nil {
+			return store.Cart{}, err
+		}
+	case store.CartStatusPaid:
+		if err := commitItems(ctx, tx, cart.Items); err != nil {
+			return store.Cart{}, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE shopping_cart SET status = ?, version = version + 1 WHERE cart_id = ?", to, cartID); err != nil {
+		return store.Cart{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return store.Cart{}, err
+	}
+
+	cart.Status = to
+	cart.Version++
+	cart.Currency = currency.String
+	cart.SetTotals()
+	return cart, nil
+}
+
+/* Internal function: items of a cart with their product names, in product_id order */
+func cartItems(ctx context.Context, tx *instrumentedTx, cartID uint64) ([]store.CartItem, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT ci.product_id, COALESCE(p.name, ''), ci.quantity, ci.unit_price
+		FROM cart_item ci
+		LEFT JOIN product p ON ci.product_id = p.product_id
+		WHERE ci.cart_id = ?
+		ORDER BY ci.product_id
+	`, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]store.CartItem, 0)
+	for rows.Next() {
+		var item store.CartItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Quantity, &item.UnitPrice); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// price and currency of a product
+type productPrice struct {
+	price    int64
+	currency string
+}
+
+/* Internal function: lock the product rows referenced by items, returns *store.ProductsNotFoundError if some are missing
+ * Returns the prices of the products by product_id */
+func lockProducts(ctx context.Context, tx *instrumentedTx, items []store.ItemUpdate) (map[int32]productPrice, error) {
+	prices := make(map[int32]productPrice, len(items))
+	if len(items) == 0 {
+		return prices, nil
+	}
+
+	productIDs := make([]any, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(productIDs)), ",")
+	productQuery := fmt.Sprintf("SELECT product_id, price, currency FROM product WHERE product_id IN (%s) FOR UPDATE", placeholders)
+	rows, err := tx.QueryContext(ctx, productQuery, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pid int32
+		var p productPrice
+		if err := rows.Scan(&pid, &p.price, &p.currency); err != nil {
+			return nil, err
+		}
+		prices[pid] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// collect products that do not exist
+	missingProducts := make([]int32, 0)
+	for _, item := range items {
+		if _, ok := prices[item.ProductID]; !ok {
+			missingProducts = append(missingProducts, item.ProductID)
+		}
+	}
+	if len(missingProducts) > 0 {
+		return nil, &store.ProductsNotFoundError{ProductIDs: missingProducts}
+	}
+	return prices, nil
+}
+
+/* Internal function: which of the products referenced by items are already in the cart */
+func itemsInCart(ctx context.Context, tx *instrumentedTx, cartID uint64, items []store.ItemUpdate) (map[int32]bool, error) {
+	inCart := make(map[int32]bool, len(items))
+	if len(items) == 0 {
+		return inCart, nil
+	}
+
+	args := make([]any, 0, len(items)+1)
+	args = append(args, cartID)
+	for _, item := range items {
+		args = append(args, item.ProductID)
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(items)), ",")
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT product_id FROM cart_item WHERE cart_id = ? AND product_id IN (%s)", placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pid int32
+		if err := rows.Scan(&pid); err != nil {
+			return nil, err
+		}
+		inCart[pid] = true
+	}
+	return inCart, rows.Err()
+}
+
+// compile-time check
+var _ store.CartStore = (*Store)(nil)
+var _ store.CartLister = (*Store)(nil)
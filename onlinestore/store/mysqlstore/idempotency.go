@@ -0,0 +1,89 @@
+package mysqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hw8-onlinestore/store"
+)
+
+/* Claim an Idempotency-Key, or return the record already stored for it (see store.IdempotencyStore)
+ * A single INSERT ... ON DUPLICATE KEY UPDATE claims new and expired keys atomically:
+ * the columns are only overwritten if the stored record has expired (affected rows: 1 inserted, 2 taken over, 0 kept). */
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key string, fingerprint string, lease time.Duration) (*store.IdempotentResponse, error) {
+	now := time.Now().UTC()
+	// expires_at is assigned last: the other assignments still compare against the stored value
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_key (idempotency_key, fingerprint, status_code, headers, body, expires_at)
+		VALUES (?, ?, 0, NULL, NULL, ?)
+		ON DUPLICATE KEY UPDATE
+			fingerprint = IF(expires_at <= ?, VALUES(fingerprint), fingerprint),
+			status_code = IF(expires_at <= ?, 0, status_code),
+			headers = IF(expires_at <= ?, NULL, headers),
+			body = IF(expires_at <= ?, NULL, body),
+			expires_at = IF(expires_at <= ?, VALUES(expires_at), expires_at)
+	`, key, fingerprint, now.Add(lease), now, now, now, now, now)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected > 0 {
+		return nil, nil // claimed
+	}
+
+	// the key is held by another request, return its record
+	var resp store.IdempotentResponse
+	var headersJSON []byte
+	err = s.db.QueryRowContext(ctx, `
+		SELECT fingerprint, status_code, headers, body
+		FROM idempotency_key
+		WHERE idempotency_key = ?
+	`, key).Scan(&resp.Fingerprint, &resp.StatusCode, &headersJSON, &resp.Body)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("idempotency key %s was released concurrently, try again", key)
+	} else if err != nil {
+		return nil, err
+	}
+	if len(headersJSON) > 0 {
+		if err := json.Unmarshal(headersJSON, &resp.Headers); err != nil {
+			return nil, fmt.Errorf("failed to parse stored headers: %w", err)
+		}
+	}
+	return &resp, nil
+}
+
+/* Store the response of the request that claimed key, and delete a batch of expired keys */
+func (s *Store) SaveIdempotentResponse(ctx context.Context, key string, resp store.IdempotentResponse, ttl time.Duration) error {
+	headersJSON, err := json.Marshal(resp.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+
+	now := time.Now().UTC()
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE idempotency_key
+		SET status_code = ?, headers = ?, body = ?, expires_at = ?
+		WHERE idempotency_key = ? AND fingerprint = ?
+	`, resp.StatusCode, headersJSON, resp.Body, now.Add(ttl), key, resp.Fingerprint)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM idempotency_key WHERE expires_at <= ? LIMIT 100", now)
+	return err
+}
+
+/* Forget a key that is still in progress */
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_key WHERE idempotency_key = ? AND status_code = 0", key)
+	return err
+}
+
+// compile-time check
+var _ store.IdempotencyStore = (*Store)(nil)
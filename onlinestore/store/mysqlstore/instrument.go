@@ -0,0 +1,115 @@
+package mysqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"hw8-onlinestore/metrics"
+	"hw8-onlinestore/tracing"
+)
+
+// backend label of the database metrics (db.system.name of the spans)
+const metricsBackend = "mysql"
+
+// define instrumentedDB struct (*sql.DB whose queries and transactions are timed in the database metrics and traced)
+// rows returned by QueryContext are timed until the first result is available, not while they are read
+type instrumentedDB struct {
+	*sql.DB
+}
+
+func (db instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := time.Now()
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	observe(ctx, "QueryRow", query, start, row.Err())
+	return row
+}
+
+func (db instrumentedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	observe(ctx, "Query", query, start, err)
+	return rows, err
+}
+
+func (db instrumentedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	res, err := db.DB.ExecContext(ctx, query, args...)
+	observe(ctx, "Exec", query, start, err)
+	return res, err
+}
+
+func (db instrumentedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*instrumentedTx, error) {
+	start := time.Now()
+	tx, err := db.DB.BeginTx(ctx, opts)
+	observe(ctx, "Begin", "", start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedTx{Tx: tx, ctx: ctx}, nil
+}
+
+// define instrumentedTx struct (*sql.Tx timed and traced like instrumentedDB, ctx is the context of BeginTx for Commit and Rollback)
+type instrumentedTx struct {
+	*sql.Tx
+	ctx context.Context
+}
+
+func (tx *instrumentedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := time.Now()
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	observe(ctx, "QueryRow", query, start, row.Err())
+	return row
+}
+
+func (tx *instrumentedTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	observe(ctx, "Query", query, start, err)
+	return rows, err
+}
+
+func (tx *instrumentedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	res, err := tx.Tx.ExecContext(ctx, query, args...)
+	observe(ctx, "Exec", query, start, err)
+	return res, err
+}
+
+func (tx *instrumentedTx) Commit() error {
+	start := time.Now()
+	err := tx.Tx.Commit()
+	observe(tx.ctx, "Commit", "", start, err)
+	return err
+}
+
+/* Rollback is deferred after every BeginTx: once the transaction is committed it does not reach the database and is not recorded */
+func (tx *instrumentedTx) Rollback() error {
+	start := time.Now()
+	err := tx.Tx.Rollback()
+	if !errors.Is(err, sql.ErrTxDone) {
+		observe(tx.ctx, "Rollback", "", start, err)
+	}
+	return err
+}
+
+/* Internal function: record one database call that started at start, in the metrics and as a client span of the request
+ * (the span is created once the call returned, which lets Rollback skip transactions that were already committed) */
+func observe(ctx context.Context, operation string, query string, start time.Time, err error) {
+	metrics.ObserveDB(ctx, metricsBackend, operation, start, err)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system.name", metricsBackend),
+		attribute.String("db.operation.name", operation),
+	}
+	if query != "" {
+		attrs = append(attrs, attribute.String("db.query.text", query)) // placeholders only, never the arguments
+	}
+	_, span := tracing.Start(ctx, "mysql."+operation,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	tracing.End(span, err)
+}
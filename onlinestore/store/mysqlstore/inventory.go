@@ -0,0 +1,213 @@
+package mysqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"hw8-onlinestore/store"
+)
+
+/* Get the inventory record of a product */
+func (s *Store) GetInventory(ctx context.Context, productID int32) (store.Inventory, error) {
+	inv := store.Inventory{ProductID: productID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(stock, 0), COALESCE(reserved, 0)
+		FROM inventory
+		WHERE product_id = ?
+	`, productID).Scan(&inv.Stock, &inv.Reserved)
+	if err == sql.ErrNoRows {
+		return store.Inventory{}, fmt.Errorf("%w: product %d is not stock-managed", store.ErrInventoryNotFound, productID)
+	} else if err != nil {
+		return store.Inventory{}, err
+	}
+	inv.Available = inv.Stock - inv.Reserved
+	return inv, nil
+}
+
+/* Create or update the stock of a product, keeping its current reservations
+ * A product that becomes stock-managed starts with the quantities already in active carts reserved
+ * Assumption: stock can not be set below the quantity already reserved by shopping carts */
+func (s *Store) SetStock(ctx context.Context, productID int32, stock uint) (store.Inventory, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Inventory{}, err
+	}
+	defer tx.Rollback()
+
+	// check if the product exists
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM product WHERE product_id = ?)", productID).Scan(&exists)
+	if err != nil {
+		return store.Inventory{}, err
+	}
+	if !exists {
+		return store.Inventory{}, fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, productID)
+	}
+
+	// lock the inventory row (if any) and check the current reservations
+	var reserved uint
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(reserved, 0) FROM inventory WHERE product_id = ? FOR UPDATE", productID).Scan(&reserved)
+	if err == sql.ErrNoRows {
+		// not stock-managed yet: reserve what active carts already hold (their rows stay locked until commit)
+		err = tx.QueryRowContext(ctx, `
+			SELECT COALESCE(SUM(ci.quantity), 0)
+			FROM cart_item ci
+			JOIN shopping_cart sc ON sc.cart_id = ci.cart_id
+			WHERE ci.product_id = ? AND sc.status = ?
+			FOR SHARE
+		`, productID, store.CartStatusActive).Scan(&reserved)
+	}
+	if err != nil {
+		return store.Inventory{}, err
+	}
+	if stock < reserved {
+		return store.Inventory{}, fmt.Errorf("%w: product %d has %d reserved units (requested stock: %d)", store.ErrStockBelowReserved, productID, reserved, stock)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO inventory (product_id, stock, reserved)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE stock = VALUES(stock)
+	`, productID, stock, reserved)
+	if err != nil {
+		return store.Inventory{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Inventory{}, err
+	}
+	return store.Inventory{ProductID: productID, Stock: stock, Reserved: reserved, Available: stock - reserved}, nil
+}
+
+/* Internal function: reserve stock for the new quantities of items in a cart (inside the caller's transaction)
+ * Only the difference with the quantity already in the cart is reserved (or released when it decreases),
+ * a decrease never releases more than the product has reserved. */
+func reserveItems(ctx context.Context, tx *instrumentedTx, cartID uint64, items []store.ItemUpdate) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	productIDs := make([]any, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(productIDs)), ",")
+
+	// lock the inventory rows of the stock-managed products
+	type stockRow struct{ stock, reserved uint }
+	inventory := make(map[int32]stockRow)
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT product_id, COALESCE(stock, 0), COALESCE(reserved, 0)
+		FROM inventory
+		WHERE product_id IN (%s)
+		FOR UPDATE
+	`, placeholders), productIDs...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var pid int32
+		var r stockRow
+		if err := rows.Scan(&pid, &r.stock, &r.reserved); err != nil {
+			rows.Close()
+			return err
+		}
+		inventory[pid] = r
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(inventory) == 0 {
+		return nil // none of the products is stock-managed
+	}
+
+	// quantities already held by this cart
+	current, err := cartQuantities(ctx, tx, cartID, productIDs)
+	if err != nil {
+		return err
+	}
+
+	// check all reservations first, then apply them
+	for _, item := range items {
+		r, ok := inventory[item.ProductID]
+		if !ok {
+			continue
+		}
+		if _, available := store.Reserve(r.stock, r.reserved, current[item.ProductID], item.Quantity); item.Quantity > available {
+			return &store.InsufficientStockError{ProductID: item.ProductID, Requested: item.Quantity, Available: available}
+		}
+	}
+	for _, item := range items {
+		r, ok := inventory[item.ProductID]
+		if !ok {
+			continue
+		}
+		reserved, _ := store.Reserve(r.stock, r.reserved, current[item.ProductID], item.Quantity)
+		if _, err := tx.ExecContext(ctx, "UPDATE inventory SET reserved = ? WHERE product_id = ?", reserved, item.ProductID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Internal function: give back the stock reserved by items (cart cancelled) */
+func releaseItems(ctx context.Context, tx *instrumentedTx, items []store.CartItem) error {
+	for _, item := range items {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE inventory
+			SET reserved = reserved - LEAST(reserved, ?)
+			WHERE product_id = ?
+		`, item.Quantity, item.ProductID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Internal function: turn the stock reserved by items into sold stock (cart paid) */
+func commitItems(ctx context.Context, tx *instrumentedTx, items []store.CartItem) error {
+	for _, item := range items {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE inventory
+			SET reserved = reserved - LEAST(reserved, ?), stock = stock - LEAST(stock, ?)
+			WHERE product_id = ?
+		`, item.Quantity, item.Quantity, item.ProductID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Internal function: quantities of the given products currently in a cart */
+func cartQuantities(ctx context.Context, tx *instrumentedTx, cartID uint64, productIDs []any) (map[int32]uint, error) {
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(productIDs)), ",")
+	args := append([]any{cartID}, productIDs...)
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT product_id, quantity
+		FROM cart_item
+		WHERE cart_id = ? AND product_id IN (%s)
+	`, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	current := make(map[int32]uint)
+	for rows.Next() {
+		var pid int32
+		var qty uint
+		if err := rows.Scan(&pid, &qty); err != nil {
+			return nil, err
+		}
+		current[pid] = qty
+	}
+	return current, rows.Err()
+}
+
+// compile-time check
+var _ store.InventoryStore = (*Store)(nil)
@@ -0,0 +1,282 @@
+package mysqlstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migration files: migrations/<version>_<name>.up.sql and the matching .down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// named lock held while migrating, so that tasks starting together do not migrate concurrently
+const (
+	migrationLockName    = "onlinestore_schema_migrations"
+	migrationLockTimeout = 5 * time.Minute
+)
+
+// define Migration struct (one up/down pair of embedded SQL files)
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// define MigrationStatus struct, AppliedAt is nil for a pending migration
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+/* Internal function: parse the embedded migration files, in version order
+ * Every version needs both an up and a down file, and versions must be unique */
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s (expected <version>_<name>.up.sql or .down.sql)", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+/* Internal function: split a migration file into statements (terminated by ';' at the end of a line),
+ * "--" comment lines are dropped */
+func splitStatements(script string) []string {
+	statements := make([]string, 0)
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+/* MigrateUp applies up to steps pending migrations in version order (all of them if steps is 0)
+ * MySQL commits DDL statements one by one: if a migration fails halfway, the statements before the failure
+ * stay applied and the migration is not recorded, so migrations are written to be re-runnable
+ * (IF [NOT] EXISTS, or an information_schema check before an ALTER TABLE since MySQL has no ADD COLUMN IF NOT EXISTS). */
+func (s *Store) MigrateUp(ctx context.Context, steps int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		done := 0
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if steps > 0 && done == steps {
+				break
+			}
+			if err := runMigration(ctx, conn, m.Version, m.Name, m.up); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now().UTC()); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "migration applied", "version", m.Version, "name", m.Name)
+			done++
+		}
+		if done == 0 {
+			slog.InfoContext(ctx, "database schema is up to date")
+		}
+		return nil
+	})
+}
+
+/* MigrateDown reverts the steps most recently applied migrations (newest first) */
+func (s *Store) MigrateDown(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("number of migrations to revert must be >= 1 (input: %d)", steps)
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		done := 0
+		for i := len(migrations) - 1; i >= 0 && done < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if err := runMigration(ctx, conn, m.Version, m.Name, m.down); err != nil {
+				return err
+			}
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "migration reverted", "version", m.Version, "name", m.Name)
+			done++
+		}
+		if done == 0 {
+			slog.InfoContext(ctx, "no applied migration to revert")
+		}
+		return nil
+	})
+}
+
+/* Migrations lists every embedded migration with the time it was applied */
+func (s *Store) Migrations(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if appliedAt, ok := applied[m.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+/* Internal function: run fn on a dedicated connection holding the migration lock (GET_LOCK is per session) */
+func (s *Store) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(migrationLockTimeout.Seconds())).Scan(&locked)
+	if err != nil {
+		return fmt.Errorf("failed to acquire the migration lock: %w", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("timed out after %s waiting for the migration lock held by another task", migrationLockTimeout)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "DO RELEASE_LOCK(?)", migrationLockName); err != nil {
+			slog.ErrorContext(ctx, "failed to release the migration lock", "error", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+/* Internal function: versions recorded in schema_migrations with their application time (creates the table if needed) */
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at DATETIME(3) NOT NULL
+		) ENGINE=InnoDB`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+/* Internal function: execute the statements of one migration file */
+func runMigration(ctx context.Context, conn *sql.Conn, version int, name string, script string) error {
+	for i, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d_%s failed at statement %d: %w", version, name, i+1, err)
+		}
+	}
+	return nil
+}
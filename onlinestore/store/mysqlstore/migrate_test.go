@@ -0,0 +1,51 @@
+package mysqlstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Fatalf("expected migration %d to have version %d, got %d_%s", i, i+1, m.Version, m.Name)
+		}
+		if len(splitStatements(m.up)) == 0 || len(splitStatements(m.down)) == 0 {
+			t.Fatalf("migration %d_%s has an empty up or down file", m.Version, m.Name)
+		}
+	}
+
+	// the initial schema is the one created before migrations, later columns are added by their own migration
+	initial := strings.Join(splitStatements(migrations[0].up), "\n")
+	for _, later := range []string{"version", "currency", "price", "unit_price", "ft_product", "idx_brand", "idempotency_key"} {
+		if strings.Contains(initial, later) {
+			t.Fatalf("expected %s to be added after %d_%s", later, migrations[0].Version, migrations[0].Name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+SET @missing = (SELECT COUNT(*) = 0 FROM information_schema.columns
+	WHERE table_name = 'product' AND column_name = 'price');
+SET @ddl = IF(@missing, 'ALTER TABLE product ADD COLUMN price BIGINT', 'DO 0');
+
+PREPARE migration FROM @ddl;
+EXECUTE migration;
+DEALLOCATE PREPARE migration`
+
+	statements := splitStatements(script)
+	if len(statements) != 5 {
+		t.Fatalf("expected 5 statements, got %d: %q", len(statements), statements)
+	}
+	if !strings.HasPrefix(statements[0], "SET @missing") || !strings.HasSuffix(statements[0], "'price');") {
+		t.Fatalf("expected the first statement to span two lines, got %q", statements[0])
+	}
+	if statements[4] != "DEALLOCATE PREPARE migration" {
+		t.Fatalf("expected the last statement without ';', got %q", statements[4])
+	}
+}
@@ -0,0 +1,6 @@
+-- Drop every table of the initial schema (children before the tables they reference).
+
+DROP TABLE IF EXISTS inventory;
+DROP TABLE IF EXISTS cart_item;
+DROP TABLE IF EXISTS shopping_cart;
+DROP TABLE IF EXISTS product;
@@ -0,0 +1,43 @@
+-- Schema of the online store as created by createDBTables before versioned migrations.
+-- Later columns and indexes are added by the following migrations.
+
+CREATE TABLE IF NOT EXISTS product (
+	product_id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255),
+	category VARCHAR(255),
+	brand VARCHAR(255),
+	description TEXT,
+	name_lowercase VARCHAR(255),
+	category_lowercase VARCHAR(255),
+	INDEX idx_name_lower (name_lowercase),
+	INDEX idx_category_lower (category_lowercase)
+) ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS shopping_cart (
+	cart_id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+	customer_id BIGINT UNSIGNED NOT NULL,
+	status ENUM('active','ordered','paid','shipped','completed','cancelled','invalid') NOT NULL DEFAULT 'active',
+	INDEX idx_customer_id (customer_id),
+	INDEX idx_status_customer (status, customer_id)
+) ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS cart_item (
+	item_id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+	product_id INT NOT NULL,
+	quantity INT UNSIGNED NOT NULL CHECK (quantity > 0),
+	cart_id BIGINT UNSIGNED NOT NULL,
+	status ENUM('valid', 'invalid') NOT NULL DEFAULT 'valid',
+	FOREIGN KEY (cart_id) REFERENCES shopping_cart(cart_id) ON DELETE CASCADE,
+	FOREIGN KEY (product_id) REFERENCES product(product_id) ON DELETE CASCADE,
+	INDEX idx_cart_id (cart_id),
+	INDEX idx_product_id (product_id),
+	UNIQUE (cart_id, product_id)
+) ENGINE=InnoDB;
+
+CREATE TABLE IF NOT EXISTS inventory (
+	product_id INT PRIMARY KEY,
+	stock INT UNSIGNED CHECK (stock >= 0),
+	reserved INT UNSIGNED CHECK (reserved >= 0),
+	FOREIGN KEY (product_id) REFERENCES product(product_id) ON DELETE CASCADE,
+	CHECK (reserved <= stock)
+) ENGINE=InnoDB;
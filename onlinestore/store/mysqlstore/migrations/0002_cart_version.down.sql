@@ -0,0 +1 @@
+ALTER TABLE shopping_cart DROP COLUMN version;
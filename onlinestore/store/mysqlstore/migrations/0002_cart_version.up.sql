@@ -0,0 +1,10 @@
+-- Cart version for ETag / If-Match.
+-- MySQL 8.0 has no ADD COLUMN IF NOT EXISTS: the ALTER only runs if the column is missing
+-- (databases created before migrations may already have it).
+
+SET @missing = (SELECT COUNT(*) = 0 FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = 'shopping_cart' AND column_name = 'version');
+SET @ddl = IF(@missing, 'ALTER TABLE shopping_cart ADD COLUMN version BIGINT UNSIGNED NOT NULL DEFAULT 1', 'DO 0');
+PREPARE migration FROM @ddl;
+EXECUTE migration;
+DEALLOCATE PREPARE migration;
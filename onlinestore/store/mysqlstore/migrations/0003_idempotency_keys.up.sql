@@ -0,0 +1,11 @@
+-- Stored responses of Idempotency-Key requests.
+
+CREATE TABLE IF NOT EXISTS idempotency_key (
+	idempotency_key VARCHAR(255) PRIMARY KEY,
+	fingerprint CHAR(64) NOT NULL,
+	status_code SMALLINT UNSIGNED NOT NULL DEFAULT 0,
+	headers JSON NULL,
+	body MEDIUMBLOB NULL,
+	expires_at DATETIME(3) NOT NULL,
+	INDEX idx_expires_at (expires_at)
+) ENGINE=InnoDB;
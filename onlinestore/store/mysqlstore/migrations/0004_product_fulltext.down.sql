@@ -0,0 +1 @@
+ALTER TABLE product DROP INDEX ft_product;
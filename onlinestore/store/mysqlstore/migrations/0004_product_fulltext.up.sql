@@ -0,0 +1,8 @@
+-- FULLTEXT index used by product search (skipped if the index already exists).
+
+SET @missing = (SELECT COUNT(*) = 0 FROM information_schema.statistics
+	WHERE table_schema = DATABASE() AND table_name = 'product' AND index_name = 'ft_product');
+SET @ddl = IF(@missing, 'ALTER TABLE product ADD FULLTEXT INDEX ft_product (name, category, brand, description)', 'DO 0');
+PREPARE migration FROM @ddl;
+EXECUTE migration;
+DEALLOCATE PREPARE migration;
@@ -0,0 +1 @@
+ALTER TABLE product DROP INDEX idx_brand;
@@ -0,0 +1,8 @@
+-- Index for the brand filter of product search (skipped if the index already exists).
+
+SET @missing = (SELECT COUNT(*) = 0 FROM information_schema.statistics
+	WHERE table_schema = DATABASE() AND table_name = 'product' AND index_name = 'idx_brand');
+SET @ddl = IF(@missing, 'ALTER TABLE product ADD INDEX idx_brand (brand)', 'DO 0');
+PREPARE migration FROM @ddl;
+EXECUTE migration;
+DEALLOCATE PREPARE migration;
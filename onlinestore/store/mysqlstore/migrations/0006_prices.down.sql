@@ -0,0 +1,4 @@
+ALTER TABLE cart_item DROP COLUMN unit_price;
+ALTER TABLE shopping_cart DROP COLUMN currency;
+ALTER TABLE product DROP COLUMN currency;
+ALTER TABLE product DROP COLUMN price;
@@ -0,0 +1,30 @@
+-- Product prices in minor units, cart currency and the unit price of cart items
+-- (each column is skipped if it already exists).
+
+SET @missing = (SELECT COUNT(*) = 0 FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = 'product' AND column_name = 'price');
+SET @ddl = IF(@missing, 'ALTER TABLE product ADD COLUMN price BIGINT NOT NULL DEFAULT 0 CHECK (price >= 0)', 'DO 0');
+PREPARE migration FROM @ddl;
+EXECUTE migration;
+DEALLOCATE PREPARE migration;
+
+SET @missing = (SELECT COUNT(*) = 0 FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = 'product' AND column_name = 'currency');
+SET @ddl = IF(@missing, 'ALTER TABLE product ADD COLUMN currency CHAR(3) NOT NULL DEFAULT ''USD''', 'DO 0');
+PREPARE migration FROM @ddl;
+EXECUTE migration;
+DEALLOCATE PREPARE migration;
+
+SET @missing = (SELECT COUNT(*) = 0 FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = 'shopping_cart' AND column_name = 'currency');
+SET @ddl = IF(@missing, 'ALTER TABLE shopping_cart ADD COLUMN currency CHAR(3) NULL', 'DO 0');
+PREPARE migration FROM @ddl;
+EXECUTE migration;
+DEALLOCATE PREPARE migration;
+
+SET @missing = (SELECT COUNT(*) = 0 FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = 'cart_item' AND column_name = 'unit_price');
+SET @ddl = IF(@missing, 'ALTER TABLE cart_item ADD COLUMN unit_price BIGINT NOT NULL DEFAULT 0', 'DO 0');
+PREPARE migration FROM @ddl;
+EXECUTE migration;
+DEALLOCATE PREPARE migration;
@@ -0,0 +1,94 @@
+package mysqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"hw8-onlinestore/metrics"
+)
+
+// define Config struct (filled from the DB_* environment variables)
+type Config struct {
+	Username string
+	Password string
+	Host     string
+	Port     string
+	Name     string
+}
+
+/* Store implements store.CartStore and store.ProductStore on top of MySQL */
+type Store struct {
+	db instrumentedDB
+}
+
+/* Open connects to MySQL and applies the pending schema migrations (see MigrateUp) */
+func Open(ctx context.Context, cfg Config) (*Store, error) {
+	s, err := Connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.MigrateUp(ctx, 0); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to migrate the database: %w", err)
+	}
+	return s, nil
+}
+
+/* Connect connects to MySQL and configures the connection pool, the schema is left as it is */
+func Connect(ctx context.Context, cfg Config) (*Store, error) {
+	if cfg.Username == "" || cfg.Password == "" || cfg.Host == "" || cfg.Port == "" || cfg.Name == "" {
+		return nil, errors.New("database environment variables are not fully set")
+	}
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Name)
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DB: %w", err)
+	}
+
+	// configure connection pool
+	db.SetMaxOpenConns(3) // max connections
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(time.Minute * 5)
+
+	// test connection
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("DB ping failed: %w", err)
+	}
+	metrics.RegisterDBStats(db, cfg.Name)
+	return &Store{db: instrumentedDB{DB: db}}, nil
+}
+
+/* Close releases the underlying connection pool */
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+/* Clear data in shopping_carts and cart_itmes tables in the database (keep tables, and product data)
+ * Reservations held by the deleted carts are released as well */
+func (s *Store) ClearCarts(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tables := []string{"cart_item", "shopping_cart"}
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", table)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE inventory SET reserved = 0"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,393 @@
+package mysqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"hw8-onlinestore/store"
+)
+
+/* Query Product in the database by product_id */
+func (s *Store) GetProduct(ctx context.Context, productID int32) (store.Product, error) {
+	query := `
+		SELECT product_id, name, category, brand, description, name_lowercase, category_lowercase, price, currency
+		FROM product
+		WHERE product_id = ?
+	`
+	var p store.Product
+	err := s.db.QueryRowContext(ctx, query, productID).Scan(
+		&p.ID,
+		&p.Name,
+		&p.Category,
+		&p.Brand,
+		&p.Description,
+		&p.NameLower,
+		&p.CategoryLower,
+		&p.Price,
+		&p.Currency,
+	)
+	if err == sql.ErrNoRows {
+		return store.Product{}, fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, productID)
+	}
+	return p, err
+}
+
+/* Update product information in the database if the product exists
+ * The price and currency are read in the same locking read and kept unless the update sets them */
+func (s *Store) UpdateProduct(ctx context.Context, u store.ProductUpdate) error {
+	// Start a transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Rollback once fail
+
+	// Check if the record exists
+	var stored store.Product
+	err = tx.QueryRowContext(ctx, "SELECT price, currency FROM product WHERE product_id = ? FOR UPDATE", u.ID).Scan(&stored.Price, &stored.Currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, u.ID)
+		}
+		return err
+	}
+	p := u.Apply(stored)
+	p.NameLower = strings.ToLower(p.Name)
+	p.CategoryLower = strings.ToLower(p.Category)
+
+	// Execute update
+	query := `
+		UPDATE product
+		SET name = ?, category = ?, brand = ?, description = ?, name_lowercase = ?, category_lowercase = ?, price = ?, currency = ?
+		WHERE product_id = ?
+	`
+	_, err = tx.ExecContext(ctx, query, p.Name, p.Category, p.Brand, p.Description, p.NameLower, p.CategoryLower, p.Price, p.Currency, p.ID)
+	if err != nil {
+		return err
+	}
+
+	// Commit
+	return tx.Commit()
+}
+
+/* Insert a new product, its product_id is assigned by AUTO_INCREMENT */
+func (s *Store) CreateProduct(ctx context.Context, p store.Product) (store.Product, error) {
+	p.NameLower = strings.ToLower(p.Name)
+	p.CategoryLower = strings.ToLower(p.Category)
+
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO product (name, category, brand, description, name_lowercase, category_lowercase, price, currency)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.Name, p.Category, p.Brand, p.Description, p.NameLower, p.CategoryLower, p.Price, p.Currency)
+	if err != nil {
+		return store.Product{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return store.Product{}, err
+	}
+	p.ID = int32(id)
+	return p, nil
+}
+
+/* Insert new products in one transaction (multi-row INSERTs of 100 products) */
+func (s *Store) ImportProducts(ctx context.Context, products []*store.Product) error {
+	for _, p := range products {
+		p.NameLower = strings.ToLower(p.Name)
+		p.CategoryLower = strings.ToLower(p.Category)
+	}
+	return s.saveProductsBatch(ctx, products, 100)
+}
+
+/* Delete a product, ON DELETE CASCADE removes its inventory and its items in shopping carts
+ * To keep placed orders intact the product is only deleted if no cart holding it is an order (active, cancelled
+ * or invalid carts lose the item), the version of those carts is increased since their items change. */
+func (s *Store) DeleteProduct(ctx context.Context, productID int32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// lock the carts holding the product, so none of them is checked out meanwhile
+	rows, err := tx.QueryContext(ctx, `
+		SELECT sc.cart_id, sc.status
+		FROM shopping_cart sc
+		JOIN cart_item ci ON ci.cart_id = sc.cart_id
+		WHERE ci.product_id = ?
+		FOR UPDATE
+	`, productID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cartID uint64
+		var status string
+		if err := rows.Scan(&cartID, &status); err != nil {
+			return err
+		}
+		if store.IsOrderStatus(status) {
+			return fmt.Errorf("%w: product %d is in shopping cart %d (status '%s')", store.ErrProductInUse, productID, cartID, status)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shopping_cart sc
+		JOIN cart_item ci ON ci.cart_id = sc.cart_id
+		SET sc.version = sc.version + 1
+		WHERE ci.product_id = ?
+	`, productID)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM product WHERE product_id = ?", productID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("%w: no product found for ID %d", store.ErrProductNotFound, productID)
+	}
+
+	return tx.Commit()
+}
+
+// InnoDB does not index words shorter than innodb_ft_min_token_size (default 3)
+const minSearchTermLength = 3
+
+// columns of the ft_product FULLTEXT index
+const fullTextColumns = "name, category, brand, description"
+
+/* Search products with the ft_product FULLTEXT index on name, category, brand and description
+ * Every term must match (boolean mode, as a word prefix), the category and brand filters are exact
+ * (case-insensitive collation). Relevance order is natural language relevance, product_id breaks ties
+ * in every order, so pages are deterministic. total_found counts all matches. */
+func (s *Store) Search(ctx context.Context, query store.SearchQuery) (store.SearchResult, error) {
+	start := time.Now()
+	result := store.SearchResult{
+		Products: make([]*store.Product, 0, query.PageSize),
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Facets:   store.SearchFacets{Categories: []store.FacetCount{}, Brands: []store.FacetCount{}},
+	}
+
+	// "+alpha* +electronics*": all terms required, prefix match
+	terms := store.SearchTerms(query.Query)
+	booleanTerms := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if len(term) >= minSearchTermLength {
+			booleanTerms = append(booleanTerms, "+"+term+"*")
+		}
+	}
+	if len(terms) > 0 && len(booleanTerms) == 0 {
+		// only terms too short to be indexed: nothing can match
+		result.SearchTime = fmt.Sprintf("%.6fs", time.Since(start).Seconds())
+		return result, nil
+	}
+	filters := searchFilters{query: query}
+	if len(booleanTerms) > 0 {
+		filters.booleanQuery = strings.Join(booleanTerms, " ")
+	}
+
+	// accurate number of matches
+	where, args := filters.where(true, true)
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM product %s", where), args...).Scan(&result.TotalFound)
+	if err != nil {
+		return store.SearchResult{}, err
+	}
+
+	// facets, each ignoring its own filter
+	if result.Facets.Categories, err = s.facetCounts(ctx, "category", filters, false, true); err != nil {
+		return store.SearchResult{}, err
+	}
+	if result.Facets.Brands, err = s.facetCounts(ctx, "brand", filters, true, false); err != nil {
+		return store.SearchResult{}, err
+	}
+
+	// requested page
+	var orderBy string
+	switch {
+	case query.Sort == store.SortName:
+		orderBy = "name, product_id"
+	case query.Sort == store.SortRelevance && filters.booleanQuery != "":
+		orderBy = fmt.Sprintf("MATCH(%s) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, product_id", fullTextColumns)
+		args = append(args, strings.Join(terms, " "))
+	default:
+		orderBy = "product_id"
+	}
+	args = append(args, query.PageSize, query.Offset())
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT product_id, name, category, brand, description, name_lowercase, category_lowercase, price, currency
+		FROM product
+		%s
+		ORDER BY %s
+		LIMIT ? OFFSET ?
+	`, where, orderBy), args...)
+	if err != nil {
+		return store.SearchResult{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p store.Product
+		err := rows.Scan(
+			&p.ID,
+			&p.Name,
+			&p.Category,
+			&p.Brand,
+			&p.Description,
+			&p.NameLower,
+			&p.CategoryLower,
+			&p.Price,
+			&p.Currency,
+		)
+		if err != nil {
+			return store.SearchResult{}, err
+		}
+		result.Products = append(result.Products, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return store.SearchResult{}, err
+	}
+
+	result.SearchTime = fmt.Sprintf("%.6fs", time.Since(start).Seconds())
+	return result, nil
+}
+
+// define searchFilters struct (WHERE conditions of a search)
+type searchFilters struct {
+	query        store.SearchQuery
+	booleanQuery string // FULLTEXT boolean mode query, empty to match every product
+}
+
+/* Internal function: WHERE clause and arguments, with or without the category and brand filters */
+func (f searchFilters) where(withCategory bool, withBrand bool) (string, []any) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 3)
+	if f.booleanQuery != "" {
+		conditions = append(conditions, fmt.Sprintf("MATCH(%s) AGAINST (? IN BOOLEAN MODE)", fullTextColumns))
+		args = append(args, f.booleanQuery)
+	}
+	if withCategory && f.query.Category != "" {
+		conditions = append(conditions, "category_lowercase = ?")
+		args = append(args, strings.ToLower(f.query.Category))
+	}
+	if withBrand && f.query.Brand != "" {
+		conditions = append(conditions, "brand = ?")
+		args = append(args, f.query.Brand)
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+/* Internal function: number of matching products per value of column */
+func (s *Store) facetCounts(ctx context.Context, column string, filters searchFilters, withCategory bool, withBrand bool) ([]store.FacetCount, error) {
+	where, args := filters.where(withCategory, withBrand)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT %s, COUNT(*) FROM product %s GROUP BY %s", column, where, column), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := make([]store.FacetCount, 0)
+	for rows.Next() {
+		var value sql.NullString
+		var facet store.FacetCount
+		if err := rows.Scan(&value, &facet.Count); err != nil {
+			return nil, err
+		}
+		facet.Value = value.String
+		facets = append(facets, facet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	store.SortFacets(facets)
+	return facets, nil
+}
+
+/* Store products with their product IDs if the product table is empty */
+func (s *Store) SeedIfEmpty(ctx context.Context, products []*store.Product) (bool, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM product)").Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if err := s.saveProductsBatch(ctx, products, 100); err != nil {
+		return false, fmt.Errorf("failed to batch insert products: %w", err)
+	}
+	slog.InfoContext(ctx, "products inserted", "products", len(products))
+	return true, nil
+}
+
+/* Internal function: Store created product data in the database in batch
+ * Products without a product_id (0) get one from AUTO_INCREMENT */
+func (s *Store) saveProductsBatch(ctx context.Context, products []*store.Product, batchSize int) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			rbErr := tx.Rollback()
+			if rbErr != nil {
+				slog.ErrorContext(ctx, "transaction rollback failed", "error", rbErr)
+			}
+		}
+	}()
+
+	// handle in batch
+	for i := 0; i < len(products); i += batchSize {
+		end := min(i+batchSize, len(products))
+		batch := products[i:end]
+
+		// Joint VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?, ?, ?, ?) ...
+		placeholders := make([]string, 0, len(batch))
+		values := make([]interface{}, 0, len(batch)*9)
+
+		for _, p := range batch {
+			var productID any // NULL: next AUTO_INCREMENT value
+			if p.ID != 0 {
+				productID = p.ID
+			}
+			placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+			values = append(values,
+				productID, p.Name, p.Category, p.Brand, p.Description, p.NameLower, p.CategoryLower, p.Price, p.Currency,
+			)
+		}
+
+		query := fmt.Sprintf(`
+			INSERT INTO product (product_id, name, category, brand, description, name_lowercase, category_lowercase, price, currency)
+			VALUES %s
+		`, strings.Join(placeholders, ","))
+
+		if _, err = tx.ExecContext(ctx, query, values...); err != nil {
+			return err // err != nil -> defer rollback automatically
+		}
+	}
+
+	return tx.Commit()
+}
+
+// compile-time check
+var _ store.ProductStore = (*Store)(nil)
+var _ store.ProductWriter = (*Store)(nil)
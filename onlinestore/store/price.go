@@ -0,0 +1,40 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// currency of products created without one (ISO 4217 code)
+const DefaultCurrency = "USD"
+
+/* SetTotals fills the line totals, item count and subtotal of a cart from the unit prices of its items */
+func (c *Cart) SetTotals() {
+	c.ItemCount = 0
+	c.Subtotal = 0
+	for i := range c.Items {
+		c.Items[i].LineTotal = c.Items[i].UnitPrice * int64(c.Items[i].Quantity)
+		c.ItemCount += c.Items[i].Quantity
+		c.Subtotal += c.Items[i].LineTotal
+	}
+}
+
+/* CartCurrency returns the currency of a cart once products priced in the given currencies (by product_id) are added
+ * A cart takes the currency of the first product added to it (cartCurrency is empty before that),
+ * products in any other currency are rejected with ErrCurrencyMismatch. */
+func CartCurrency(cartID string, cartCurrency string, added map[int32]string) (string, error) {
+	productIDs := make([]int32, 0, len(added))
+	for pid := range added {
+		productIDs = append(productIDs, pid)
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	for _, pid := range productIDs {
+		if cartCurrency == "" {
+			cartCurrency = added[pid]
+		} else if added[pid] != cartCurrency {
+			return "", fmt.Errorf("%w: product %d is priced in %s, shopping cart %s is in %s", ErrCurrencyMismatch, pid, added[pid], cartID, cartCurrency)
+		}
+	}
+	return cartCurrency, nil
+}
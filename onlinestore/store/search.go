@@ -0,0 +1,147 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// search sort orders
+const (
+	SortRelevance = "relevance" // most relevant first (product_id order without query)
+	SortName      = "name"
+	SortID        = "id"
+)
+
+// define SearchQuery struct
+// Category and Brand are optional exact (case-insensitive) filters, Page starts at 1
+type SearchQuery struct {
+	Query    string
+	Category string
+	Brand    string
+	Sort     string
+	Page     int
+	PageSize int
+}
+
+// define search facet structs
+// category counts ignore the category filter and brand counts ignore the brand filter,
+// so a sidebar can show how many products every other choice would return
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+type SearchFacets struct {
+	Categories []FacetCount `json:"categories"`
+	Brands     []FacetCount `json:"brands"`
+}
+
+/* Offset returns the number of results before the requested page */
+func (q SearchQuery) Offset() int {
+	return (max(q.Page, 1) - 1) * q.PageSize
+}
+
+/* SearchTerms splits a search query into lowercase words (letters and digits only)
+ * Every term must match the beginning of a word of the product (name, category, brand or description) */
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+/* MatchScore returns the relevance of a product for the terms of a query (the MySQL FULLTEXT semantics for backends
+ * that search in Go): every term must match the beginning of a word of its name, category, brand or description,
+ * the score is the number of matching words. It is -1 if a term matches no word. */
+func MatchScore(p *Product, terms []string) int {
+	words := SearchTerms(strings.Join([]string{p.Name, p.Category, p.Brand, p.Description}, " "))
+	score := 0
+	for _, term := range terms {
+		termScore := 0
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				termScore++
+			}
+		}
+		if termScore == 0 {
+			return -1
+		}
+		score += termScore
+	}
+	return score
+}
+
+/* IsSearchSort reports whether sort is a supported search order */
+func IsSearchSort(sort string) bool {
+	return sort == SortRelevance || sort == SortName || sort == SortID
+}
+
+// define ScoredProduct struct (a product matching the query terms and its relevance)
+type ScoredProduct struct {
+	Product *Product
+	Score   int
+}
+
+/* PageSearchResults applies the category and brand filters, facets, sort order and pagination of query
+ * to the products matching its terms (for backends that search in Go). SearchTime is left to the caller. */
+func PageSearchResults(matches []ScoredProduct, query SearchQuery) SearchResult {
+	categories := make(map[string]int)
+	brands := make(map[string]int)
+	filtered := make([]ScoredProduct, 0, len(matches))
+	for _, m := range matches {
+		categoryOK := query.Category == "" || strings.EqualFold(m.Product.Category, query.Category)
+		brandOK := query.Brand == "" || strings.EqualFold(m.Product.Brand, query.Brand)
+		if brandOK {
+			categories[m.Product.Category]++
+		}
+		if categoryOK {
+			brands[m.Product.Brand]++
+		}
+		if categoryOK && brandOK {
+			filtered = append(filtered, m)
+		}
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		switch {
+		case query.Sort == SortName && a.Product.Name != b.Product.Name:
+			return a.Product.Name < b.Product.Name
+		case query.Sort == SortRelevance && a.Score != b.Score:
+			return a.Score > b.Score
+		}
+		return a.Product.ID < b.Product.ID
+	})
+
+	result := SearchResult{
+		Products:   make([]*Product, 0, query.PageSize),
+		TotalFound: len(filtered),
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		Facets:     SearchFacets{Categories: facetCounts(categories), Brands: facetCounts(brands)},
+	}
+	offset := min(query.Offset(), len(filtered))
+	for _, m := range filtered[offset:min(offset+query.PageSize, len(filtered))] {
+		result.Products = append(result.Products, m.Product)
+	}
+	return result
+}
+
+/* Internal function: facet counts, most frequent value first */
+func facetCounts(counts map[string]int) []FacetCount {
+	facets := make([]FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, FacetCount{Value: value, Count: count})
+	}
+	SortFacets(facets)
+	return facets
+}
+
+/* SortFacets orders facet counts by decreasing count, then by value */
+func SortFacets(facets []FacetCount) {
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+)
+
+// shopping cart statuses (values of the shopping_cart.status ENUM)
+const (
+	CartStatusOrdered   = "ordered"
+	CartStatusPaid      = "paid"
+	CartStatusShipped   = "shipped"
+	CartStatusCompleted = "completed"
+	CartStatusCancelled = "cancelled"
+	CartStatusInvalid   = "invalid"
+)
+
+// every status a cart can have
+var cartStatuses = []string{
+	CartStatusActive, CartStatusOrdered, CartStatusPaid, CartStatusShipped,
+	CartStatusCompleted, CartStatusCancelled, CartStatusInvalid,
+}
+
+/* IsCartStatus reports whether status is a known shopping cart status */
+func IsCartStatus(status string) bool {
+	return slices.Contains(cartStatuses, status)
+}
+
+// statuses of carts that were checked out and not cancelled (placed orders)
+var orderStatuses = []string{CartStatusOrdered, CartStatusPaid, CartStatusShipped, CartStatusCompleted}
+
+/* IsOrderStatus reports whether a cart in status is a placed order (ordered, paid, shipped or completed) */
+func IsOrderStatus(status string) bool {
+	return slices.Contains(orderStatuses, status)
+}
+
+/* cartTransitions lists the statuses a cart may move to from each status
+ * completed, cancelled and invalid are terminal */
+var cartTransitions = map[string][]string{
+	CartStatusActive:  {CartStatusOrdered, CartStatusCancelled},
+	CartStatusOrdered: {CartStatusPaid, CartStatusCancelled},
+	CartStatusPaid:    {CartStatusShipped},
+	CartStatusShipped: {CartStatusCompleted},
+}
+
+/* CanTransition reports whether a cart in status from may move to status to */
+func CanTransition(from string, to string) bool {
+	return slices.Contains(cartTransitions[from], to)
+}
+
+/* TransitionSources returns the statuses from which a cart may move to status to */
+func TransitionSources(to string) []string {
+	sources := make([]string, 0)
+	for from, targets := range cartTransitions {
+		if slices.Contains(targets, to) {
+			sources = append(sources, from)
+		}
+	}
+	slices.Sort(sources)
+	return sources
+}
+
+/* InvalidTransitionError is returned when a cart can not move from its current status to the requested one */
+type InvalidTransitionError struct {
+	CartID string
+	From   string
+	To     string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("Shopping cart %s can not move from '%s' to '%s'", e.CartID, e.From, e.To)
+}
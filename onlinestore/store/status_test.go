@@ -0,0 +1,53 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{CartStatusActive, CartStatusOrdered}:    true,
+		{CartStatusActive, CartStatusCancelled}:  true,
+		{CartStatusOrdered, CartStatusPaid}:      true,
+		{CartStatusOrdered, CartStatusCancelled}: true,
+		{CartStatusPaid, CartStatusShipped}:      true,
+		{CartStatusShipped, CartStatusCompleted}: true,
+	}
+	// every pair of statuses, terminal statuses and staying in the same status included
+	for _, from := range cartStatuses {
+		for _, to := range cartStatuses {
+			t.Run(from+" to "+to, func(t *testing.T) {
+				if got, want := CanTransition(from, to), allowed[[2]string{from, to}]; got != want {
+					t.Fatalf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+				}
+			})
+		}
+	}
+	if CanTransition("unknown", CartStatusOrdered) || CanTransition(CartStatusActive, "unknown") {
+		t.Fatalf("expected unknown statuses to have no transitions")
+	}
+}
+
+func TestTransitionSources(t *testing.T) {
+	tests := []struct {
+		to   string
+		want []string
+	}{
+		{CartStatusActive, []string{}},
+		{CartStatusOrdered, []string{CartStatusActive}},
+		{CartStatusPaid, []string{CartStatusOrdered}},
+		{CartStatusShipped, []string{CartStatusPaid}},
+		{CartStatusCompleted, []string{CartStatusShipped}},
+		{CartStatusCancelled, []string{CartStatusActive, CartStatusOrdered}},
+		{CartStatusInvalid, []string{}},
+		{"unknown", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.to, func(t *testing.T) {
+			if got := TransitionSources(tt.to); !slices.Equal(got, tt.want) {
+				t.Fatalf("TransitionSources(%s) = %v, want %v", tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// constants
+const CartStatusActive = "active"
+
+// define Product struct
+// price is in minor units of currency (e.g. cents of USD)
+type Product struct {
+	ID            int32  `json:"product_id" binding:"required,gte=1"`
+	Name          string `json:"name" binding:"required,min=1"`
+	Category      string `json:"category" binding:"required,min=1"`
+	Description   string `json:"description" binding:"required,min=1"`
+	Brand         string `json:"brand" binding:"required,min=1"`
+	Price         int64  `json:"price" binding:"gte=0"`
+	Currency      string `json:"currency" binding:"omitempty,iso4217"`
+	NameLower     string `json:"-"` // used for search, excluded in response
+	CategoryLower string `json:"-"` // used for search, excluded in response
+}
+
+// define ProductUpdate struct (request body of a product details update)
+// price and currency are optional, the stored values are kept when they are omitted
+type ProductUpdate struct {
+	ID          int32   `json:"product_id" binding:"required,gte=1"`
+	Name        string  `json:"name" binding:"required,min=1"`
+	Category    string  `json:"category" binding:"required,min=1"`
+	Description string  `json:"description" binding:"required,min=1"`
+	Brand       string  `json:"brand" binding:"required,min=1"`
+	Price       *int64  `json:"price" binding:"omitempty,gte=0"`
+	Currency    *string `json:"currency" binding:"omitempty,iso4217"`
+}
+
+/* Apply returns the stored product p with the update applied (price and currency only if the update sets them) */
+func (u ProductUpdate) Apply(p Product) Product {
+	p.ID = u.ID
+	p.Name = u.Name
+	p.Category = u.Category
+	p.Description = u.Description
+	p.Brand = u.Brand
+	if u.Price != nil {
+		p.Price = *u.Price
+	}
+	if u.Currency != nil {
+		p.Currency = *u.Currency
+	}
+	return p
+}
+
+// define SearchResult struct
+// total_found counts every matching product, products only holds the requested page
+type SearchResult struct {
+	Products   []*Product   `json:"products"`
+	TotalFound int          `json:"total_found"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	Facets     SearchFacets `json:"facets"`
+	SearchTime string       `json:"search_time"`
+}
+
+// define shopping cart structs
+// cart IDs are opaque strings: MySQL renders its numeric key, DynamoDB uses a UUID
+// unit_price is the product price when the product was added to the cart, prices are in minor units of the cart currency
+type CartItem struct {
+	ProductID   int32  `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    uint   `json:"quantity"`
+	UnitPrice   int64  `json:"unit_price"`
+	LineTotal   int64  `json:"line_total"`
+}
+
+// version starts at 1 and increases with every change of the cart (items or status), the API exposes it as the ETag
+// currency is set by the first product added to the cart, item_count is the sum of the item quantities
+type Cart struct {
+	CartID     string     `json:"cart_id"`
+	CustomerID uint64     `json:"customer_id"`
+	Status     string     `json:"status"`
+	Version    uint64     `json:"version"`
+	Currency   string     `json:"currency,omitempty"`
+	Items      []CartItem `json:"items"`
+	ItemCount  uint       `json:"item_count"`
+	Subtotal   int64      `json:"subtotal"`
+}
+
+// define update shopping cart struct
+type ItemUpdate struct {
+	ProductID int32
+	Quantity  uint
+}
+
+/* CartStore is implemented by every shopping cart backend
+ * Create only creates a cart if the customer does not have an active cart yet
+ * UpsertItems adds or overwrites the quantity of each listed product (ON DUPLICATE KEY UPDATE semantics),
+ * a quantity of 0 removes the product from the cart (nothing happens if it is not in the cart),
+ * it fails with ErrCartNotActive once the cart left the active status.
+ * If ifVersion is not 0 the update only happens if the cart is still at that version (ErrVersionMismatch otherwise),
+ * the new version of the cart is returned. Backends may limit the items of one update (ErrTooManyItems, 99 in DynamoDB)
+ * Transition moves the cart to another status following CanTransition and returns the updated cart:
+ *   - ordered requires at least one item (ErrCartEmpty)
+ *   - cancelled releases the inventory reserved by the cart
+ *   - paid turns the reservations into sold stock (stock and reserved both decrease) */
+type CartStore interface {
+	Create(ctx context.Context, customerID uint64) (Cart, error)
+	Get(ctx context.Context, cartID string) (Cart, error)
+	UpsertItems(ctx context.Context, cartID string, items []ItemUpdate, ifVersion uint64) (uint64, error)
+	Transition(ctx context.Context, cartID string, to string) (Cart, error)
+}
+
+// define cart listing structs
+// carts are listed newest first
+type CartSummary struct {
+	CartID     string `json:"cart_id"`
+	CustomerID uint64 `json:"customer_id"`
+	Status     string `json:"status"`
+}
+type CartPage struct {
+	Carts      []CartSummary `json:"carts"`
+	NextCursor string        `json:"next_cursor,omitempty"` // empty on the last page
+}
+type CartListQuery struct {
+	CustomerID uint64
+	Status     string // optional status filter
+	Limit      int
+	Cursor     string // NextCursor of the previous page, empty for the first page
+}
+
+/* CartLister is implemented by backends that can list the carts of a customer page by page
+ * An unknown or malformed cursor fails with ErrInvalidCursor */
+type CartLister interface {
+	ListCarts(ctx context.Context, query CartListQuery) (CartPage, error)
+}
+
+/* ProductStore is implemented by backends that host the product catalog
+ * Update only updates an existing product and returns ErrProductNotFound otherwise,
+ * the price and currency are only changed when the update sets them
+ * Search returns one page of the products matching every term of the query (all products for an empty query)
+ * and the filters, in the requested order (product_id order among equal products), with category and brand facets */
+type ProductStore interface {
+	GetProduct(ctx context.Context, productID int32) (Product, error)
+	UpdateProduct(ctx context.Context, p ProductUpdate) error
+	Search(ctx context.Context, query SearchQuery) (SearchResult, error)
+}
+
+/* ProductWriter is implemented by product backends that can add and delete products
+ * CreateProduct assigns the product_id of the new product, ImportProducts stores a batch of new products
+ * (the backend assigns their product IDs). DeleteProduct returns ErrProductInUse if the product is in a placed order (IsOrderStatus),
+ * otherwise it is removed from the carts holding it (active, cancelled or invalid, MySQL ON DELETE CASCADE) and their version changes. */
+type ProductWriter interface {
+	CreateProduct(ctx context.Context, p Product) (Product, error)
+	ImportProducts(ctx context.Context, products []*Product) error
+	DeleteProduct(ctx context.Context, productID int32) error
+}
+
+// define Inventory struct
+// reserved counts the units held by shopping carts, available = stock - reserved
+type Inventory struct {
+	ProductID int32 `json:"product_id"`
+	Stock     uint  `json:"stock"`
+	Reserved  uint  `json:"reserved"`
+	Available uint  `json:"available"`
+}
+
+/* InventoryStore is implemented by backends that track stock per product
+ * Products without an inventory record are not stock-managed and can always be added to carts.
+ * For stock-managed products, UpsertItems reserves the quantity difference and fails with
+ * *InsufficientStockError (without changing anything) when the reservation would exceed the stock. */
+type InventoryStore interface {
+	GetInventory(ctx context.Context, productID int32) (Inventory, error)
+	SetStock(ctx context.Context, productID int32, stock uint) (Inventory, error)
+}
+
+/* Seeder is implemented by backends that can fill an empty product catalog with generated products
+ * SeedIfEmpty stores products with their product IDs unless the catalog has products, and reports whether it did */
+type Seeder interface {
+	SeedIfEmpty(ctx context.Context, products []*Product) (bool, error)
+}
+
+/* CartClearer is implemented by backends that support wiping all cart data (debug endpoint) */
+type CartClearer interface {
+	ClearCarts(ctx context.Context) error
+}
+
+// define errors shared by all backends
+var (
+	ErrInvalidCartID    = errors.New("invalid shopping cart id")
+	ErrCartNotFound     = errors.New("shopping cart not found")
+	ErrProductNotFound  = errors.New("product not found")
+	ErrProductInUse     = errors.New("product is in an order")
+	ErrCurrencyMismatch = errors.New("product currency does not match the shopping cart")
+	ErrCartNotActive    = errors.New("shopping cart is not active")
+	ErrCartEmpty        = errors.New("shopping cart has no items")
+	ErrInvalidCursor    = errors.New("invalid page cursor")
+	ErrTooManyItems     = errors.New("too many items in one update")
+	ErrVersionMismatch  = errors.New("shopping cart version does not match")
+	ErrConcurrentUpdate = errors.New("shopping cart is being changed by concurrent requests")
+
+	ErrInventoryNotFound  = errors.New("inventory not found")
+	ErrStockBelowReserved = errors.New("stock is lower than the reserved quantity")
+)
+
+/* ActiveCartExistsError is returned by Create when the customer already has an active cart */
+type ActiveCartExistsError struct {
+	CustomerID uint64
+	CartID     string
+}
+
+func (e *ActiveCartExistsError) Error() string {
+	return fmt.Sprintf("Customer %d has an active shopping cart (id = %s)", e.CustomerID, e.CartID)
+}
+
+/* ProductsNotFoundError is returned by UpsertItems when some of the referenced products do not exist */
+type ProductsNotFoundError struct {
+	ProductIDs []int32
+}
+
+func (e *ProductsNotFoundError) Error() string {
+	return fmt.Sprintf("Product IDs %v not found", e.ProductIDs)
+}
+
+func (e *ProductsNotFoundError) Unwrap() error {
+	return ErrProductNotFound
+}
+
+/* InsufficientStockError is returned when a reservation would exceed the available stock of a product */
+type InsufficientStockError struct {
+	ProductID int32
+	Requested uint
+	Available uint
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("Product %d: requested quantity %d exceeds available stock %d", e.ProductID, e.Requested, e.Available)
+}
+
+/* PartialWriteError is returned when a backend could only apply part of a batch of writes
+ * (e.g. DynamoDB kept returning UnprocessedItems under throttling), the written items are not rolled back */
+type PartialWriteError struct {
+	Written int
+	Failed  int
+}
+
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("%d of %d writes were not processed after retries (%d written)", e.Failed, e.Written+e.Failed, e.Written)
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/* InstrumentAWS is an AWS API option (aws.Config.APIOptions) giving every SDK call a client span
+ * named after the service and operation (DynamoDB.TransactWriteItems, SNS.Publish, ...), SDK retries included. */
+func InstrumentAWS(stack *middleware.Stack) error {
+	// after RegisterServiceMetadata, which puts the service name and region in the context
+	return stack.Initialize.Insert(middleware.InitializeMiddlewareFunc("OnlinestoreTracing", traceAWS), "RegisterServiceMetadata", middleware.After)
+}
+
+/* Internal function: initialize step middleware, runs once per call around the retries */
+func traceAWS(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	service := awsmiddleware.GetServiceID(ctx)
+	operation := middleware.GetOperationName(ctx)
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.system", "aws-api"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", operation),
+		attribute.String("cloud.region", awsmiddleware.GetRegion(ctx)),
+	}
+	if table := tableName(in.Parameters); table != "" {
+		attrs = append(attrs, attribute.StringSlice("aws.dynamodb.table_names", []string{table}))
+	}
+
+	ctx, span := Start(ctx, service+"."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	if requestID, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		span.SetAttributes(attribute.String("aws.request_id", requestID))
+	}
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		span.SetAttributes(attribute.Int("http.response.status_code", responseErr.HTTPStatusCode()))
+	}
+	End(span, err)
+	return out, metadata, err
+}
+
+/* Internal function: TableName of a single-table DynamoDB input ("" for other inputs) */
+func tableName(input any) string {
+	value := reflect.ValueOf(input)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	field := value.Elem().FieldByName("TableName")
+	if !field.IsValid() {
+		return ""
+	}
+	if table, ok := field.Interface().(*string); ok && table != nil {
+		return *table
+	}
+	return ""
+}
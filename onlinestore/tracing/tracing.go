@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation scope of the spans
+const scope = "hw8-onlinestore"
+
+var tracer = otel.Tracer(scope)
+
+/* Setup installs the global tracer provider and the W3C trace context (traceparent, tracestate) and baggage propagators
+ * exporter is otlp (OTLP over HTTP, OTEL_EXPORTER_OTLP_* variables), stdout (one JSON span per line, for local runs)
+ * or none (default): spans are then not recorded, but incoming trace context is still passed on.
+ * Sampling follows OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG (every trace by default, parent decision first).
+ * The returned function flushes the spans not exported yet and stops the exporter. */
+func Setup(ctx context.Context, service string, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var processor sdktrace.SpanProcessor
+	switch strings.ToLower(exporter) {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		e, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the OTLP trace exporter: %w", err)
+		}
+		processor = sdktrace.NewBatchSpanProcessor(e)
+	case "stdout", "console":
+		e, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the stdout trace exporter: %w", err)
+		}
+		// exported as soon as they end, nothing is lost when a local run is interrupted
+		processor = sdktrace.NewSimpleSpanProcessor(e)
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER '%s' (expected otlp, stdout or none)", exporter)
+	}
+
+	// service.name can be overridden by OTEL_SERVICE_NAME or OTEL_RESOURCE_ATTRIBUTES
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+/* Start starts a span named name, child of the span carried by ctx (if any) */
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, opts...)
+}
+
+/* End ends span, marking it failed with err when err is not nil */
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+/* Inject writes the trace context of ctx to carrier (e.g. message attributes) */
+func Inject(ctx context.Context, carrier map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(carrier))
+}
+
+/* Extract returns ctx with the trace context read from carrier, ctx unchanged if there is none */
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+/* Fields returns the keys the propagators read and write (traceparent, tracestate, baggage) */
+func Fields() []string {
+	return otel.GetTextMapPropagator().Fields()
+}
@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrInvalidReceipt is returned for a receipt handle that is unknown or no longer current
+var ErrInvalidReceipt = errors.New("receipt handle is invalid or expired")
+
+/* MemoryQueue is a local stand-in for SQS with the same visibility timeout semantics:
+ * a received message is hidden until its timeout expires, then it is delivered again with a new receipt handle. */
+type MemoryQueue struct {
+	mu                sync.Mutex
+	visibilityTimeout time.Duration
+	messages          []*memoryMessage
+	nextID            int
+	nextReceipt       int
+	notify            chan struct{} // closed and replaced when a message is sent
+}
+
+// internal message record
+type memoryMessage struct {
+	id           string
+	body         string
+	receipt      string
+	visibleAt    time.Time
+	receiveCount int
+}
+
+/* NewMemoryQueue returns an empty queue hiding received messages for visibilityTimeout */
+func NewMemoryQueue(visibilityTimeout time.Duration) *MemoryQueue {
+	return &MemoryQueue{
+		visibilityTimeout: visibilityTimeout,
+		notify:            make(chan struct{}),
+	}
+}
+
+/* Send enqueues a message body and returns its message ID */
+func (q *MemoryQueue) Send(body string) string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.nextID++
+	m := &memoryMessage{id: strconv.Itoa(q.nextID), body: body}
+	q.messages = append(q.messages, m)
+
+	close(q.notify)
+	q.notify = make(chan struct{})
+	return m.id
+}
+
+/* Len returns the number of messages not deleted yet (visible or in flight) */
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.messages)
+}
+
+/* ReceiveCount returns how many times the message id was delivered (0 if it was deleted) */
+func (q *MemoryQueue) ReceiveCount(id string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, m := range q.messages {
+		if m.id == id {
+			return m.receiveCount
+		}
+	}
+	return 0
+}
+
+/* Receive returns up to maxMessages visible messages, waiting up to wait for one to arrive (long polling) */
+func (q *MemoryQueue) Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]Message, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		q.mu.Lock()
+		now := time.Now()
+		received := make([]Message, 0, maxMessages)
+		nextVisible := deadline
+		for _, m := range q.messages {
+			if len(received) == maxMessages {
+				break
+			}
+			if m.visibleAt.After(now) {
+				if m.visibleAt.Before(nextVisible) {
+					nextVisible = m.visibleAt
+				}
+				continue
+			}
+			q.nextReceipt++
+			m.receipt = m.id + "-" + strconv.Itoa(q.nextReceipt)
+			m.visibleAt = now.Add(q.visibilityTimeout)
+			m.receiveCount++
+			received = append(received, Message{ID: m.id, ReceiptHandle: m.receipt, Body: m.body})
+		}
+		notify := q.notify
+		q.mu.Unlock()
+
+		if len(received) > 0 || !now.Before(deadline) {
+			return received, nil
+		}
+
+		// wait for a new message, a hidden message becoming visible again, or the end of the long poll
+		timer := time.NewTimer(time.Until(nextVisible))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+func (q *MemoryQueue) ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	m := q.find(receiptHandle)
+	if m == nil {
+		return ErrInvalidReceipt
+	}
+	m.visibleAt = time.Now().Add(timeout)
+	return nil
+}
+
+func (q *MemoryQueue) Delete(ctx context.Context, receiptHandle string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, m := range q.messages {
+		if m.receipt != "" && m.receipt == receiptHandle {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			return nil
+		}
+	}
+	return ErrInvalidReceipt
+}
+
+/* Internal function: message currently held with receiptHandle (caller holds q.mu) */
+func (q *MemoryQueue) find(receiptHandle string) *memoryMessage {
+	for _, m := range q.messages {
+		if m.receipt != "" && m.receipt == receiptHandle {
+			return m
+		}
+	}
+	return nil
+}
+
+// compile-time check
+var _ Queue = (*MemoryQueue)(nil)
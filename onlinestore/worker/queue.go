@@ -0,0 +1,23 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// define Message struct (one received queue message)
+type Message struct {
+	ID            string
+	ReceiptHandle string
+	Body          string
+	Attributes    map[string]string // string message attributes (trace context of the publisher)
+}
+
+/* Queue is the part of SQS the worker needs
+ * Received messages stay invisible to other consumers until their visibility timeout expires,
+ * ExtendVisibility pushes that deadline and Delete acknowledges the message. */
+type Queue interface {
+	Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]Message, error)
+	ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error
+	Delete(ctx context.Context, receiptHandle string) error
+}
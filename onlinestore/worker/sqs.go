@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"hw8-onlinestore/tracing"
+)
+
+/* SQSQueue implements Queue on top of an SQS queue (order-processing-queue) */
+type SQSQueue struct {
+	client   *sqs.Client
+	queueURL string
+}
+
+/* NewSQSQueue returns a Queue reading queueURL through client */
+func NewSQSQueue(client *sqs.Client, queueURL string) *SQSQueue {
+	return &SQSQueue{client: client, queueURL: queueURL}
+}
+
+func (q *SQSQueue) Receive(ctx context.Context, maxMessages int, wait time.Duration) ([]Message, error) {
+	out, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(q.queueURL),
+		MaxNumberOfMessages: int32(min(maxMessages, 10)), // SQS limit
+		WaitTimeSeconds:     int32(wait / time.Second),   // long polling
+		// raw message delivery keeps the SNS message attributes as SQS message attributes
+		MessageAttributeNames: tracing.Fields(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(out.Messages))
+	for _, m := range out.Messages {
+		messages = append(messages, Message{
+			ID:            aws.ToString(m.MessageId),
+			ReceiptHandle: aws.ToString(m.ReceiptHandle),
+			Body:          aws.ToString(m.Body),
+			Attributes:    stringAttributes(m.MessageAttributes),
+		})
+	}
+	return messages, nil
+}
+
+func (q *SQSQueue) ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	_, err := q.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(q.queueURL),
+		ReceiptHandle:     aws.String(receiptHandle),
+		VisibilityTimeout: int32(timeout / time.Second),
+	})
+	return err
+}
+
+func (q *SQSQueue) Delete(ctx context.Context, receiptHandle string) error {
+	_, err := q.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(q.queueURL),
+		ReceiptHandle: aws.String(receiptHandle),
+	})
+	return err
+}
+
+/* Internal function: the String attributes of a message */
+func stringAttributes(attributes map[string]types.MessageAttributeValue) map[string]string {
+	values := make(map[string]string, len(attributes))
+	for key, attribute := range attributes {
+		if aws.ToString(attribute.DataType) == "String" {
+			values[key] = aws.ToString(attribute.StringValue)
+		}
+	}
+	return values
+}
+
+// compile-time check
+var _ Queue = (*SQSQueue)(nil)
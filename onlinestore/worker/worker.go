@@ -0,0 +1,216 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"hw8-onlinestore/events"
+	"hw8-onlinestore/store"
+	"hw8-onlinestore/tracing"
+)
+
+// defaults matching the order-processing-queue settings in terraform
+const (
+	DefaultWaitTime          = 20 * time.Second // receive_wait_time_seconds
+	DefaultVisibilityTimeout = 30 * time.Second // visibility_timeout_seconds
+	DefaultProcessTimeout    = 5 * time.Minute
+)
+
+/* Worker consumes OrderPlaced events: every order is marked paid, which commits its reserved inventory
+ * A message is deleted once it is handled (or can never be handled), otherwise it is left on the queue
+ * and SQS delivers it again when its visibility timeout expires. */
+type Worker struct {
+	queue             Queue
+	carts             store.CartStore
+	concurrency       int
+	waitTime          time.Duration
+	visibilityTimeout time.Duration
+	processTimeout    time.Duration
+}
+
+/* New returns a Worker running concurrency pollers against queue */
+func New(queue Queue, carts store.CartStore, concurrency int) *Worker {
+	return &Worker{
+		queue:             queue,
+		carts:             carts,
+		concurrency:       max(concurrency, 1),
+		waitTime:          DefaultWaitTime,
+		visibilityTimeout: DefaultVisibilityTimeout,
+		processTimeout:    DefaultProcessTimeout,
+	}
+}
+
+/* WithTimeouts overrides the long-polling wait and the visibility timeout (e.g. for a local queue) */
+func (w *Worker) WithTimeouts(wait time.Duration, visibilityTimeout time.Duration) *Worker {
+	w.waitTime = wait
+	w.visibilityTimeout = visibilityTimeout
+	return w
+}
+
+/* Run polls the queue until ctx is cancelled, then waits for the messages in flight to finish (graceful shutdown) */
+func (w *Worker) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.poll(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+/* Internal function: one poller, receives and handles a message at a time */
+func (w *Worker) poll(ctx context.Context) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		messages, err := w.queue.Receive(ctx, 1, w.waitTime)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.ErrorContext(ctx, "failed to receive messages", "error", err, "retry_in", backoff.String())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, 30*time.Second)
+			continue
+		}
+		backoff = time.Second
+
+		for _, m := range messages {
+			// in-flight messages are finished even if shutdown was requested meanwhile
+			w.handle(context.WithoutCancel(ctx), m)
+		}
+	}
+}
+
+/* Internal function: process one message while keeping it invisible, delete it when done */
+func (w *Worker) handle(ctx context.Context, m Message) {
+	ctx, cancel := context.WithTimeout(ctx, w.processTimeout)
+	defer cancel()
+
+	// consumer span in the trace of the request that published the event
+	ctx, span := tracing.Start(tracing.Extract(ctx, m.Attributes), "process OrderPlaced",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("messaging.system", "aws_sqs"), attribute.String("messaging.message.id", m.ID)))
+
+	stopHeartbeat := w.keepInvisible(ctx, m)
+	err := w.process(ctx, m.Body)
+	stopHeartbeat()
+	defer tracing.End(span, err) // after the delete
+
+	var permanent *permanentError
+	switch {
+	case err == nil:
+	case errors.As(err, &permanent):
+		slog.WarnContext(ctx, "dropping message", "message_id", m.ID, "error", err)
+	default:
+		slog.ErrorContext(ctx, "failed to process message, it will be retried", "message_id", m.ID, "error", err)
+		return
+	}
+
+	if err := w.queue.Delete(ctx, m.ReceiptHandle); err != nil {
+		slog.ErrorContext(ctx, "failed to delete message", "message_id", m.ID, "error", err)
+	}
+}
+
+/* Internal function: extend the visibility timeout of m every half timeout until the returned stop function is called */
+func (w *Worker) keepInvisible(ctx context.Context, m Message) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(w.visibilityTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.queue.ExtendVisibility(ctx, m.ReceiptHandle, w.visibilityTimeout); err != nil {
+					slog.WarnContext(ctx, "failed to extend visibility", "message_id", m.ID, "error", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// permanentError marks messages that can never be processed (they are deleted instead of retried)
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// statuses meaning the order was already processed (redelivered message)
+var processedStatuses = []string{store.CartStatusPaid, store.CartStatusShipped, store.CartStatusCompleted}
+
+/* Internal function: decode an OrderPlaced event and mark its cart paid */
+func (w *Worker) process(ctx context.Context, body string) error {
+	event, err := decodeOrderPlaced(body)
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	_, err = w.carts.Transition(ctx, event.CartID, store.CartStatusPaid)
+	var invalidTransition *store.InvalidTransitionError
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "order paid", "cart_id", event.CartID, "customer_id", event.CustomerID, "items", len(event.Items))
+		return nil
+	case errors.As(err, &invalidTransition) && slices.Contains(processedStatuses, invalidTransition.From):
+		slog.InfoContext(ctx, "order already processed", "cart_id", event.CartID, "status", invalidTransition.From)
+		return nil
+	case errors.As(err, &invalidTransition), errors.Is(err, store.ErrCartNotFound), errors.Is(err, store.ErrInvalidCartID):
+		return &permanentError{err}
+	default:
+		return err
+	}
+}
+
+/* Internal function: parse a message body, accepting raw SNS delivery (the event itself) or an SNS notification envelope */
+func decodeOrderPlaced(body string) (events.OrderPlaced, error) {
+	var envelope struct {
+		Type    string `json:"Type"`
+		Message string `json:"Message"`
+	}
+	if err := json.Unmarshal([]byte(body), &envelope); err == nil && envelope.Type == "Notification" {
+		body = envelope.Message
+	}
+
+	var event events.OrderPlaced
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		return events.OrderPlaced{}, fmt.Errorf("invalid event body: %w", err)
+	}
+	if event.EventType != events.OrderPlacedType {
+		return events.OrderPlaced{}, fmt.Errorf("unexpected event type '%s'", event.EventType)
+	}
+	if event.Version != events.OrderPlacedVersion {
+		return events.OrderPlaced{}, fmt.Errorf("unsupported %s version %d", event.EventType, event.Version)
+	}
+	if event.CartID == "" {
+		return events.OrderPlaced{}, errors.New("event has no cart_id")
+	}
+	return event, nil
+}
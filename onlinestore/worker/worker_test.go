@@ -0,0 +1,207 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hw8-onlinestore/events"
+	"hw8-onlinestore/store"
+	"hw8-onlinestore/store/memstore"
+)
+
+// short timeouts so redeliveries happen within a test
+const (
+	testWait              = 20 * time.Millisecond
+	testVisibilityTimeout = 100 * time.Millisecond
+)
+
+// slowStore delays Transition and fails its first calls, on top of an in-memory backend
+type slowStore struct {
+	store.CartStore
+	delay    time.Duration
+	failures int32         // number of Transition calls failing before the backend is called
+	started  chan struct{} // closed when Transition is first called
+	calls    atomic.Int32
+	once     sync.Once
+}
+
+func (s *slowStore) Transition(ctx context.Context, cartID string, to string) (store.Cart, error) {
+	s.once.Do(func() { close(s.started) })
+	if s.calls.Add(1) <= s.failures {
+		return store.Cart{}, context.DeadlineExceeded
+	}
+	time.Sleep(s.delay)
+	return s.CartStore.Transition(ctx, cartID, to)
+}
+
+/* Internal function: in-memory backend with one ordered cart, and the body of its OrderPlaced event */
+func orderedCart(t *testing.T) (*memstore.Store, string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	s := memstore.New()
+	s.AddProducts([]*store.Product{{ID: 1, Name: "Product", Price: 100, Currency: store.DefaultCurrency}})
+	cart, err := s.Create(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to create cart: %v", err)
+	}
+	if _, err := s.UpsertItems(ctx, cart.CartID, []store.ItemUpdate{{ProductID: 1, Quantity: 2}}, 0); err != nil {
+		t.Fatalf("failed to add item: %v", err)
+	}
+	cart, err = s.Transition(ctx, cart.CartID, store.CartStatusOrdered)
+	if err != nil {
+		t.Fatalf("failed to check out cart: %v", err)
+	}
+
+	body, err := json.Marshal(events.NewOrderPlaced(cart))
+	if err != nil {
+		t.Fatalf("failed to encode event: %v", err)
+	}
+	return s, cart.CartID, string(body)
+}
+
+/* Internal function: run a worker until the returned stop function is called, stop waits for Run to return */
+func runWorker(queue *MemoryQueue, carts store.CartStore, concurrency int) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		New(queue, carts, concurrency).WithTimeouts(testWait, testVisibilityTimeout).Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+/* Internal function: wait until condition is true, fail the test after timeout */
+func waitFor(t *testing.T, timeout time.Duration, condition func() bool, what string) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+/* Internal function: fail the test unless the cart has the expected status */
+func expectCartStatus(t *testing.T, s *memstore.Store, cartID string, status string) {
+	t.Helper()
+
+	cart, err := s.Get(context.Background(), cartID)
+	if err != nil {
+		t.Fatalf("failed to get cart: %v", err)
+	}
+	if cart.Status != status {
+		t.Fatalf("expected cart %s to be '%s', got '%s'", cartID, status, cart.Status)
+	}
+}
+
+func TestWorkerDeletesAfterSuccess(t *testing.T) {
+	s, cartID, body := orderedCart(t)
+	queue := NewMemoryQueue(testVisibilityTimeout)
+	queue.Send(body)
+
+	stop := runWorker(queue, s, 1)
+	defer stop()
+
+	waitFor(t, time.Second, func() bool { return queue.Len() == 0 }, "the message to be deleted")
+	expectCartStatus(t, s, cartID, store.CartStatusPaid)
+}
+
+func TestWorkerRedeliversAfterFailure(t *testing.T) {
+	s, cartID, body := orderedCart(t)
+	carts := &slowStore{CartStore: s, failures: 1, started: make(chan struct{})}
+	queue := NewMemoryQueue(testVisibilityTimeout)
+	queue.Send(body)
+
+	stop := runWorker(queue, carts, 1)
+	defer stop()
+
+	// the failed message stays on the queue and comes back once its visibility timeout expires
+	<-carts.started
+	if queue.Len() != 1 {
+		t.Fatalf("expected the failed message to stay on the queue")
+	}
+	waitFor(t, time.Second, func() bool { return queue.Len() == 0 }, "the message to be delivered again and deleted")
+
+	if calls := carts.calls.Load(); calls != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", calls)
+	}
+	expectCartStatus(t, s, cartID, store.CartStatusPaid)
+}
+
+func TestWorkerExtendsVisibility(t *testing.T) {
+	s, cartID, body := orderedCart(t)
+	carts := &slowStore{CartStore: s, delay: 4 * testVisibilityTimeout, started: make(chan struct{})}
+	queue := NewMemoryQueue(testVisibilityTimeout)
+	id := queue.Send(body)
+
+	// a second poller would receive the message again if its visibility was not extended
+	stop := runWorker(queue, carts, 2)
+	defer stop()
+
+	<-carts.started
+	waitFor(t, 2*carts.delay, func() bool { return queue.Len() == 0 }, "the message to be deleted")
+
+	if count := queue.ReceiveCount(id); count != 0 {
+		t.Fatalf("expected the message to be deleted, it was received %d times", count)
+	}
+	if calls := carts.calls.Load(); calls != 1 {
+		t.Fatalf("expected the message to be handled once, got %d", calls)
+	}
+	expectCartStatus(t, s, cartID, store.CartStatusPaid)
+}
+
+func TestWorkerDrainsOnShutdown(t *testing.T) {
+	s, cartID, body := orderedCart(t)
+	carts := &slowStore{CartStore: s, delay: 2 * testVisibilityTimeout, started: make(chan struct{})}
+	queue := NewMemoryQueue(testVisibilityTimeout)
+	queue.Send(body)
+
+	stop := runWorker(queue, carts, 1)
+
+	// shutdown requested while the message is being handled: Run returns once it is done
+	<-carts.started
+	stop()
+
+	if queue.Len() != 0 {
+		t.Fatalf("expected the in-flight message to be deleted before shutdown")
+	}
+	expectCartStatus(t, s, cartID, store.CartStatusPaid)
+}
+
+func TestWorkerDropsPoisonMessages(t *testing.T) {
+	s, _, body := orderedCart(t)
+	var event events.OrderPlaced
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	event.CartID = "999"
+	unknownCart, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to encode event: %v", err)
+	}
+
+	carts := &slowStore{CartStore: s, started: make(chan struct{})}
+	queue := NewMemoryQueue(testVisibilityTimeout)
+	queue.Send(string(unknownCart))
+	queue.Send("not an event")
+
+	stop := runWorker(queue, carts, 1)
+	defer stop()
+
+	// both are deleted instead of being retried forever
+	waitFor(t, time.Second, func() bool { return queue.Len() == 0 }, "the messages to be deleted")
+	time.Sleep(2 * testVisibilityTimeout)
+	if calls := carts.calls.Load(); calls != 1 {
+		t.Fatalf("expected the unknown cart to be tried once, got %d", calls)
+	}
+}